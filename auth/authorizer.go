@@ -0,0 +1,37 @@
+package auth
+
+import "strings"
+
+// Authorizer decides whether a Principal may act on a given
+// project/builder pair, read from a build's Info map.
+type Authorizer struct{}
+
+// NewAuthorizer returns an Authorizer. It takes no arguments today; it
+// exists as a type so enforcement call sites don't need to change if
+// authorization grows configuration of its own (e.g. an admin override
+// list) later.
+func NewAuthorizer() *Authorizer {
+	return &Authorizer{}
+}
+
+// Allowed reports whether principal may act on the given project/builder.
+// An empty Projects (or Builders) list on the principal means "no
+// restriction on this dimension", as for an admin principal configured
+// without prefixes.
+// Prefix matching lets a single entry like "sys-perf" cover every variant
+// project Evergreen spins up for it.
+func (a *Authorizer) Allowed(principal Principal, project, builder string) bool {
+	return matchesAnyPrefix(principal.Projects, project) && matchesAnyPrefix(principal.Builders, builder)
+}
+
+func matchesAnyPrefix(prefixes []string, value string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizerAllowsUnrestrictedPrincipal(t *testing.T) {
+	a := NewAuthorizer()
+	assert.True(t, a.Allowed(Principal{}, "sys-perf", "linux-64-perf"))
+}
+
+func TestAuthorizerMatchesProjectPrefix(t *testing.T) {
+	a := NewAuthorizer()
+	principal := Principal{Projects: []string{"sys-perf"}}
+
+	assert.True(t, a.Allowed(principal, "sys-perf-4.4", "any-builder"))
+	assert.False(t, a.Allowed(principal, "mongodb-mongo-master", "any-builder"))
+}
+
+func TestAuthorizerRequiresBothProjectAndBuilderMatch(t *testing.T) {
+	a := NewAuthorizer()
+	principal := Principal{Projects: []string{"sys-perf"}, Builders: []string{"linux"}}
+
+	assert.True(t, a.Allowed(principal, "sys-perf", "linux-64"))
+	assert.False(t, a.Allowed(principal, "sys-perf", "windows-64"))
+}
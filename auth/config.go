@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// StaticTokenEntry is one principal's entry in a Config's Static or HMAC
+// tables: the credential lookup key plus the project/builder prefixes it
+// is authorized for.
+type StaticTokenEntry struct {
+	ID       string   `yaml:"id"`
+	Token    string   `yaml:"token"`
+	Projects []string `yaml:"projects"`
+	Builders []string `yaml:"builders"`
+}
+
+// Config is the on-disk shape of --authConfig.
+type Config struct {
+	// StaticTokens authenticates callers presenting one of these bearer
+	// tokens directly.
+	StaticTokens []StaticTokenEntry `yaml:"static_tokens"`
+
+	// HMACSecret, if set, enables the shared-secret Evergreen
+	// authenticator, authorizing the principals listed in HMACPrincipals.
+	HMACSecret     string             `yaml:"hmac_secret"`
+	HMACPrincipals []StaticTokenEntry `yaml:"hmac_principals"`
+
+	// OIDC, if set, enables JWT validation against an external issuer.
+	OIDC *OIDCConfig `yaml:"oidc"`
+
+	// RateLimitPerSecond and RateLimitBurst configure the per-principal
+	// token bucket. A RateLimitPerSecond of zero disables rate limiting.
+	RateLimitPerSecond float64 `yaml:"rate_limit_per_second"`
+	RateLimitBurst     int     `yaml:"rate_limit_burst"`
+}
+
+// OIDCConfig configures JWT validation against an external issuer.
+type OIDCConfig struct {
+	Issuer        string `yaml:"issuer"`
+	Audience      string `yaml:"audience"`
+	ProjectsClaim string `yaml:"projects_claim"`
+	BuildersClaim string `yaml:"builders_claim"`
+}
+
+// LoadConfig reads and parses an auth config file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading auth config '%s'", path)
+	}
+
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, errors.Wrapf(err, "parsing auth config '%s'", path)
+	}
+	return config, nil
+}
+
+// BuildMiddleware constructs the Middleware described by this config. It
+// combines every configured authenticator (a request satisfies the first
+// one that recognizes its credentials) with an Authorizer and, if
+// configured, a RateLimiter.
+func (c *Config) BuildMiddleware(ctx context.Context) (*Middleware, error) {
+	var authenticators []Authenticator
+
+	if len(c.StaticTokens) > 0 {
+		authenticators = append(authenticators, NewStaticTokenAuthenticator(c.StaticTokens))
+	}
+
+	if c.HMACSecret != "" {
+		authenticators = append(authenticators, NewHMACAuthenticator(c.HMACSecret, c.HMACPrincipals))
+	}
+
+	if c.OIDC != nil {
+		oidcAuth, err := NewOIDCAuthenticator(ctx, c.OIDC.Issuer, c.OIDC.Audience, c.OIDC.ProjectsClaim, c.OIDC.BuildersClaim)
+		if err != nil {
+			return nil, errors.Wrap(err, "building OIDC authenticator")
+		}
+		authenticators = append(authenticators, oidcAuth)
+	}
+
+	if len(authenticators) == 0 {
+		return nil, errors.New("auth config defines no authenticators")
+	}
+
+	var limiter *RateLimiter
+	if c.RateLimitPerSecond > 0 {
+		limiter = NewRateLimiter(c.RateLimitPerSecond, c.RateLimitBurst)
+	}
+
+	return NewMiddleware(authenticators, NewAuthorizer(), limiter), nil
+}
@@ -0,0 +1,7 @@
+package auth
+
+import "github.com/pkg/errors"
+
+// ErrNoCredentials is returned by an Authenticator when the request
+// carries no credentials at all, as opposed to invalid ones.
+var ErrNoCredentials = errors.New("request carries no credentials")
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// hmacSignatureHeader and hmacPrincipalHeader are the shared-secret
+// request headers Evergreen signs when calling into logkeeper directly.
+const (
+	hmacSignatureHeader = "X-Logkeeper-Signature"
+	hmacPrincipalHeader = "X-Logkeeper-Principal"
+)
+
+// HMACAuthenticator authenticates requests signed with a secret shared
+// with Evergreen: the caller sends its principal id in
+// X-Logkeeper-Principal and an HMAC-SHA256 of "<principal>:<path>" in
+// X-Logkeeper-Signature.
+type HMACAuthenticator struct {
+	secret     []byte
+	principals map[string]Principal
+}
+
+// NewHMACAuthenticator builds an authenticator that validates signatures
+// against secret and resolves the signed principal id against entries.
+func NewHMACAuthenticator(secret string, entries []StaticTokenEntry) *HMACAuthenticator {
+	principals := make(map[string]Principal, len(entries))
+	for _, e := range entries {
+		principals[e.ID] = Principal{ID: e.ID, Projects: e.Projects, Builders: e.Builders}
+	}
+	return &HMACAuthenticator{secret: []byte(secret), principals: principals}
+}
+
+func (a *HMACAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	principalID := r.Header.Get(hmacPrincipalHeader)
+	signature := r.Header.Get(hmacSignatureHeader)
+	if principalID == "" && signature == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	if principalID == "" || signature == "" {
+		return Principal{}, errors.New("both principal and signature headers are required")
+	}
+
+	principal, ok := a.principals[principalID]
+	if !ok {
+		return Principal{}, errors.New("unrecognized principal")
+	}
+
+	expected := a.sign(principalID, r.URL.Path)
+	given, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(given, expected) {
+		return Principal{}, errors.New("invalid request signature")
+	}
+	return principal, nil
+}
+
+func (a *HMACAuthenticator) sign(principalID, path string) []byte {
+	mac := hmac.New(sha256.New, a.secret)
+	_, _ = mac.Write([]byte(principalID + ":" + path))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mongodb/grip"
+)
+
+// writeMethods are the HTTP methods used by the ingestion endpoints
+// (POST /build, POST /build/{id}/test, POST .../log). Middleware requires
+// authentication on these; GET requests are allowed through anonymously
+// unless a handler later finds the build is private and calls Allowed
+// itself with the principal this middleware attached to the request
+// context.
+var writeMethods = map[string]bool{
+	http.MethodPost: true,
+	http.MethodPut:  true,
+}
+
+// Middleware is a negroni-style handler that authenticates requests,
+// attaches the resulting Principal to the request context, and applies a
+// per-principal rate limit. It does not by itself reject reads of private
+// builds: that requires knowing the build's project/builder, which only
+// the handler has loaded, so handlers call Authorizer.Allowed themselves
+// using the Principal from PrincipalFromContext.
+type Middleware struct {
+	authenticators []Authenticator
+	authorizer     *Authorizer
+	limiter        *RateLimiter
+}
+
+// NewMiddleware builds a Middleware. Authenticators are tried in order;
+// the first to recognize the request's credentials wins.
+func NewMiddleware(authenticators []Authenticator, authorizer *Authorizer, limiter *RateLimiter) *Middleware {
+	return &Middleware{authenticators: authenticators, authorizer: authorizer, limiter: limiter}
+}
+
+// Authorizer returns the Authorizer this middleware was built with, so
+// handlers can reuse it for build-level private/project checks.
+func (m *Middleware) Authorizer() *Authorizer {
+	return m.authorizer
+}
+
+func (m *Middleware) authenticate(r *http.Request) (Principal, error) {
+	var lastErr error
+	for _, a := range m.authenticators {
+		principal, err := a.Authenticate(r)
+		if err == nil {
+			return principal, nil
+		}
+		if err != ErrNoCredentials {
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return Principal{}, lastErr
+	}
+	return Principal{}, ErrNoCredentials
+}
+
+// ServeHTTP implements negroni.Handler.
+func (m *Middleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	principal, err := m.authenticate(r)
+	switch {
+	case err == nil:
+		r = r.WithContext(WithPrincipal(r.Context(), principal))
+	case err == ErrNoCredentials && !isIngestionPath(r):
+		// Anonymous read: let the handler decide based on whether the
+		// build is private.
+	default:
+		grip.Debug(err)
+		http.Error(rw, "invalid or missing credentials", http.StatusUnauthorized)
+		return
+	}
+
+	if m.limiter != nil {
+		key := principal.ID
+		if key == "" {
+			key = "anonymous"
+		}
+		if !m.limiter.Allow(key) {
+			http.Error(rw, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	next(rw, r)
+}
+
+func isIngestionPath(r *http.Request) bool {
+	return writeMethods[r.Method] && strings.HasPrefix(r.URL.Path, "/build")
+}
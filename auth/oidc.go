@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/pkg/errors"
+)
+
+// OIDCAuthenticator validates bearer tokens as JWTs issued by a
+// configured OIDC issuer. The verifier caches the issuer's JWKS
+// internally and refetches it as keys rotate.
+type OIDCAuthenticator struct {
+	verifier    *oidc.IDTokenVerifier
+	projectsKey string
+	buildersKey string
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// NewOIDCAuthenticator discovers issuer's OIDC configuration and JWKS
+// endpoint and returns an authenticator that validates tokens against it.
+// projectsClaim/buildersClaim name the JWT claims holding the caller's
+// allowed project/builder prefixes.
+func NewOIDCAuthenticator(ctx context.Context, issuer, audience, projectsClaim, buildersClaim string) (*OIDCAuthenticator, error) {
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "discovering OIDC provider '%s'", issuer)
+	}
+
+	return &OIDCAuthenticator{
+		verifier:    provider.Verifier(&oidc.Config{ClientID: audience}),
+		projectsKey: projectsClaim,
+		buildersKey: buildersClaim,
+	}, nil
+}
+
+func (a *OIDCAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	rawToken := strings.TrimPrefix(header, "Bearer ")
+	if rawToken == header {
+		return Principal{}, errors.New("Authorization header is not a bearer token")
+	}
+
+	idToken, err := a.verifier.Verify(r.Context(), rawToken)
+	if err != nil {
+		a.recordErr(err)
+		return Principal{}, errors.Wrap(err, "verifying OIDC token")
+	}
+
+	var claims struct {
+		Subject  string   `json:"sub"`
+		Projects []string `json:"-"`
+		Builders []string `json:"-"`
+	}
+	var raw map[string]interface{}
+	if err := idToken.Claims(&raw); err != nil {
+		return Principal{}, errors.Wrap(err, "decoding OIDC claims")
+	}
+	claims.Subject = idToken.Subject
+	claims.Projects = stringSliceClaim(raw, a.projectsKey)
+	claims.Builders = stringSliceClaim(raw, a.buildersKey)
+
+	return Principal{ID: claims.Subject, Projects: claims.Projects, Builders: claims.Builders}, nil
+}
+
+func (a *OIDCAuthenticator) recordErr(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastErr = err
+}
+
+func stringSliceClaim(claims map[string]interface{}, key string) []string {
+	raw, ok := claims[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// jwksRefreshInterval documents the default interval go-oidc's key set
+// uses internally to refetch JWKS; kept here for operators tuning cache
+// behavior via the issuer's Cache-Control headers.
+const jwksRefreshInterval = 15 * time.Minute
@@ -0,0 +1,41 @@
+// Package auth adds authentication and per-project authorization to the
+// ingestion and read endpoints. It is optional: logkeeper runs anonymous
+// (today's behavior) unless main.go is given an auth config.
+package auth
+
+import (
+	"context"
+	"net/http"
+)
+
+// Principal identifies the caller an Authenticator extracted from a
+// request, and the project/builder prefixes an Authorizer will allow it
+// to act on.
+type Principal struct {
+	ID       string
+	Projects []string
+	Builders []string
+}
+
+// Authenticator extracts a Principal from an incoming request. It returns
+// ErrNoCredentials when the request carries none, so callers can
+// distinguish "anonymous" from "invalid".
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying principal, retrievable with
+// PrincipalFromContext.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the Principal attached by the auth
+// middleware, if any. ok is false for anonymous requests (no auth
+// configured, or the request hit a route the middleware doesn't guard).
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter bounds request throughput per principal, independent of
+// logkeeper's overall --maxRequestSize, so one noisy caller can't starve
+// the rest.
+type RateLimiter struct {
+	rps   rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewRateLimiter returns a limiter allowing up to rps requests/second per
+// principal, with bursts up to burst.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rps:      rate.Limit(rps),
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// Allow reports whether principalID may make a request right now,
+// consuming from its bucket if so.
+func (rl *RateLimiter) Allow(principalID string) bool {
+	return rl.limiterFor(principalID).Allow()
+}
+
+func (rl *RateLimiter) limiterFor(principalID string) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	limiter, ok := rl.limiters[principalID]
+	if !ok {
+		limiter = rate.NewLimiter(rl.rps, rl.burst)
+		rl.limiters[principalID] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// StaticTokenAuthenticator authenticates callers against a fixed table of
+// bearer tokens, loaded once at startup from the auth config file.
+type StaticTokenAuthenticator struct {
+	tokens map[string]Principal
+}
+
+// NewStaticTokenAuthenticator builds an authenticator from a config's
+// token entries.
+func NewStaticTokenAuthenticator(entries []StaticTokenEntry) *StaticTokenAuthenticator {
+	tokens := make(map[string]Principal, len(entries))
+	for _, e := range entries {
+		tokens[e.Token] = Principal{ID: e.ID, Projects: e.Projects, Builders: e.Builders}
+	}
+	return &StaticTokenAuthenticator{tokens: tokens}
+}
+
+func (a *StaticTokenAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == header {
+		return Principal{}, errors.New("Authorization header is not a bearer token")
+	}
+
+	principal, ok := a.tokens[token]
+	if !ok {
+		return Principal{}, errors.New("unrecognized bearer token")
+	}
+	return principal, nil
+}
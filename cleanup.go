@@ -0,0 +1,135 @@
+package logkeeper
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/evergreen-ci/logkeeper/db"
+	"github.com/evergreen-ci/logkeeper/env"
+	"github.com/evergreen-ci/logkeeper/logstore"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeletedStats reports what CleanupOldLogsAndTestsByBuild actually
+// removed, so a caller sweeping many builds can log a meaningful summary.
+type DeletedStats struct {
+	NumBuilds int
+	NumTests  int
+	NumLogs   int
+}
+
+// GetOldBuilds returns up to batchSize builds that started more than
+// deletePassedTestCutoff ago and have not been marked failed (see
+// UpdateFailedBuild).
+func GetOldBuilds(batchSize int) ([]LogKeeperBuild, error) {
+	cursor, err := db.C(buildsCollection).Find(env.Context(), bson.M{
+		"started": bson.M{"$lt": time.Now().Add(-currentDeletePassedTestCutoff())},
+		"failed":  bson.M{"$ne": true},
+	}, options.Find().SetLimit(int64(batchSize)))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding old builds")
+	}
+	defer cursor.Close(env.Context())
+
+	var builds []LogKeeperBuild
+	if err := cursor.All(env.Context(), &builds); err != nil {
+		return nil, errors.Wrap(err, "decoding old builds")
+	}
+	return builds, nil
+}
+
+// UpdateFailedBuild marks a build as failed, exempting it from GetOldBuilds
+// (and therefore from cleanup) regardless of age.
+func UpdateFailedBuild(buildID string) error {
+	_, err := db.C(buildsCollection).UpdateOne(env.Context(),
+		bson.M{"_id": buildID}, bson.M{"$set": bson.M{"failed": true}})
+	return errors.Wrapf(err, "marking build '%s' failed", buildID)
+}
+
+// CleanupOldLogsAndTestsByBuild deletes buildID's tests and logs, along
+// with any blobs CurrentLogStore holds for them. It claims the build via
+// ClaimBuildForCleanup first, so that when multiple workers race to clean
+// up the same build under --queueType=remote, only the winner proceeds;
+// every other caller sees mongo.ErrNoDocuments from the claim and returns
+// a zero DeletedStats rather than erroring or double-deleting.
+func CleanupOldLogsAndTestsByBuild(buildID string) (DeletedStats, error) {
+	var stats DeletedStats
+
+	_, err := ClaimBuildForCleanup(buildID)
+	if err == mongo.ErrNoDocuments {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	stats.NumBuilds = 1
+
+	testsResult, err := db.C(testsCollection).DeleteMany(env.Context(), bson.M{"build_id": buildID})
+	if err != nil {
+		return stats, errors.Wrapf(err, "deleting tests for build '%s'", buildID)
+	}
+	stats.NumTests = int(testsResult.DeletedCount)
+
+	numLogs, err := deleteLogsForBuild(buildID)
+	if err != nil {
+		return stats, err
+	}
+	stats.NumLogs = numLogs
+
+	if _, err := db.C(logSeqCollection).DeleteMany(env.Context(), bson.M{
+		"_id": bson.M{"$regex": "^" + regexp.QuoteMeta(buildID) + "(/.*)?$"},
+	}); err != nil {
+		return stats, errors.Wrapf(err, "deleting log sequence counters for build '%s'", buildID)
+	}
+
+	return stats, nil
+}
+
+// deleteLogsForBuild deletes buildID's log metadata documents, batch
+// deleting any blobs CurrentLogStore holds for them first so an
+// object-storage-backed store doesn't accumulate unreferenced objects.
+func deleteLogsForBuild(buildID string) (int, error) {
+	if store := CurrentLogStore(); store != nil {
+		refs, err := blobRefsForBuild(buildID)
+		if err != nil {
+			return 0, err
+		}
+		if len(refs) > 0 {
+			if err := store.Delete(env.Context(), refs...); err != nil {
+				return 0, errors.Wrapf(err, "deleting log blobs for build '%s'", buildID)
+			}
+		}
+	}
+
+	result, err := db.C(logsCollection).DeleteMany(env.Context(), bson.M{"build_id": buildID})
+	if err != nil {
+		return 0, errors.Wrapf(err, "deleting log metadata for build '%s'", buildID)
+	}
+	return int(result.DeletedCount), nil
+}
+
+// blobRefsForBuild returns the non-empty BlobRef of every log document
+// belonging to buildID.
+func blobRefsForBuild(buildID string) ([]logstore.BlobRef, error) {
+	cursor, err := db.C(logsCollection).Find(env.Context(), bson.M{"build_id": buildID})
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding logs for build '%s'", buildID)
+	}
+	defer cursor.Close(env.Context())
+
+	var docs []Log
+	if err := cursor.All(env.Context(), &docs); err != nil {
+		return nil, errors.Wrapf(err, "decoding logs for build '%s'", buildID)
+	}
+
+	var refs []logstore.BlobRef
+	for _, doc := range docs {
+		if doc.BlobRef != "" {
+			refs = append(refs, logstore.BlobRef(doc.BlobRef))
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,28 @@
+package logkeeper
+
+import (
+	"github.com/evergreen-ci/logkeeper/db"
+	"github.com/evergreen-ci/logkeeper/env"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ClaimBuildForCleanup atomically removes buildID's document from
+// buildsCollection and returns it, so that when multiple queue workers
+// race to clean up the same build (as can happen once cleanup jobs run on
+// a distributed queue) only the worker that wins the FindOneAndDelete
+// goes on to delete the build's tests and logs. Workers that lose the
+// race get mongo.ErrNoDocuments and should treat the build as already
+// cleaned up rather than erroring.
+func ClaimBuildForCleanup(buildID string) (*LogKeeperBuild, error) {
+	var build LogKeeperBuild
+	err := db.C(buildsCollection).FindOneAndDelete(env.Context(), bson.M{"_id": buildID}).Decode(&build)
+	if err == mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "claiming build '%s' for cleanup", buildID)
+	}
+	return &build, nil
+}
@@ -0,0 +1,137 @@
+// Package config defines logkeeper's YAML configuration file, the
+// counterpart to main.go's flags. A Config can be loaded at startup and
+// reloaded on SIGHUP without requiring a restart for the fields that
+// support it; see Config.ApplyReloadable.
+package config
+
+import (
+	"io/ioutil"
+	"time"
+
+	"github.com/evergreen-ci/logkeeper"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/send"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Config mirrors main.go's flags, plus the queue-tuning and cleanup
+// settings that previously required a restart to change.
+type Config struct {
+	Port           int    `yaml:"port"`
+	DBHost         string `yaml:"db_host"`
+	RSName         string `yaml:"rs_name"`
+	LogPath        string `yaml:"log_path"`
+	MaxRequestSize int    `yaml:"max_request_size"`
+
+	LogStoreType string `yaml:"log_store"`
+	S3Bucket     string `yaml:"s3_bucket"`
+	S3Endpoint   string `yaml:"s3_endpoint"`
+	S3Region     string `yaml:"s3_region"`
+
+	QueueType          string        `yaml:"queue_type"`
+	AmboyWorkers       int           `yaml:"amboy_workers"`
+	AmboyTargetNumJobs int           `yaml:"amboy_target_num_jobs"`
+	AmboyInterval      time.Duration `yaml:"amboy_interval"`
+
+	CleanupBatchSize       int           `yaml:"cleanup_batch_size"`
+	DeletePassedTestCutoff time.Duration `yaml:"delete_passed_test_cutoff"`
+
+	AuthConfigPath string `yaml:"auth_config"`
+	LogLevel       string `yaml:"log_level"`
+}
+
+// Default returns the Config matching main.go's flag defaults, so a
+// deployment can start from an empty file and override only what it
+// needs.
+func Default() *Config {
+	return &Config{
+		Port:                   8080,
+		DBHost:                 "localhost:27017",
+		LogPath:                "logkeeperapp.log",
+		MaxRequestSize:         1024 * 1024 * 32,
+		LogStoreType:           "mongo",
+		S3Region:               "us-east-1",
+		QueueType:              "local",
+		AmboyWorkers:           4,
+		AmboyTargetNumJobs:     1,
+		AmboyInterval:          time.Minute,
+		CleanupBatchSize:       1000,
+		DeletePassedTestCutoff: 14 * 24 * time.Hour,
+		LogLevel:               "info",
+	}
+}
+
+// LoadConfig reads and parses a YAML config file, starting from
+// Default() so that fields the file omits keep their flag-equivalent
+// defaults.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading config '%s'", path)
+	}
+
+	c := Default()
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrapf(err, "parsing config '%s'", path)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "config '%s' is invalid", path)
+	}
+	return c, nil
+}
+
+// ApplyReloadable applies the subset of Config that can take effect
+// without a restart: the cleanup batch size and cutoff, and the grip
+// sender's log level. It's called once at startup and again on every
+// SIGHUP reload, so both paths stay in sync.
+func (c *Config) ApplyReloadable() error {
+	logkeeper.SetCleanupBatchSize(c.CleanupBatchSize)
+	logkeeper.SetDeletePassedTestCutoff(c.DeletePassedTestCutoff)
+
+	priority := level.FromString(c.LogLevel)
+	if priority == level.Invalid {
+		return errors.Errorf("invalid log_level '%s'", c.LogLevel)
+	}
+	return errors.Wrap(grip.Sender().SetLevel(send.LevelInfo{Default: priority, Threshold: priority}), "setting log level")
+}
+
+// Validate checks that Config's values are internally consistent.
+func (c *Config) Validate() error {
+	var errs []string
+	if c.Port <= 0 {
+		errs = append(errs, "port must be positive")
+	}
+	if c.MaxRequestSize <= 0 {
+		errs = append(errs, "max_request_size must be positive")
+	}
+	switch c.LogStoreType {
+	case "", "mongo":
+	case "s3":
+		if c.S3Bucket == "" {
+			errs = append(errs, "s3_bucket is required when log_store is 's3'")
+		}
+	default:
+		errs = append(errs, "log_store must be 'mongo' or 's3'")
+	}
+	switch c.QueueType {
+	case "", "local", "remote":
+	default:
+		errs = append(errs, "queue_type must be 'local' or 'remote'")
+	}
+	if c.AmboyWorkers <= 0 {
+		errs = append(errs, "amboy_workers must be positive")
+	}
+	if c.CleanupBatchSize <= 0 {
+		errs = append(errs, "cleanup_batch_size must be positive")
+	}
+	if c.DeletePassedTestCutoff <= 0 {
+		errs = append(errs, "delete_passed_test_cutoff must be positive")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.Errorf("invalid config: %v", errs)
+}
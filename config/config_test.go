@@ -0,0 +1,53 @@
+package config
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	assert.NoError(t, Default().Validate())
+}
+
+func TestLoadConfigRoundTrips(t *testing.T) {
+	original := Default()
+	original.Port = 9090
+	original.CleanupBatchSize = 500
+	original.DeletePassedTestCutoff = 48 * time.Hour
+	original.LogStoreType = "s3"
+	original.S3Bucket = "logkeeper-logs"
+
+	data, err := yaml.Marshal(original)
+	require.NoError(t, err)
+
+	f, err := ioutil.TempFile(t.TempDir(), "logkeeper-config-*.yaml")
+	require.NoError(t, err)
+	_, err = f.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	loaded, err := LoadConfig(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, original, loaded)
+}
+
+func TestLoadConfigRejectsInvalidFile(t *testing.T) {
+	f, err := ioutil.TempFile(t.TempDir(), "logkeeper-config-*.yaml")
+	require.NoError(t, err)
+	_, err = f.WriteString("port: -1\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = LoadConfig(f.Name())
+	assert.Error(t, err)
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	_, err := LoadConfig("/does/not/exist.yaml")
+	assert.Error(t, err)
+}
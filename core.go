@@ -0,0 +1,154 @@
+package logkeeper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/evergreen-ci/logkeeper/auth"
+	"github.com/evergreen-ci/logkeeper/livelog"
+	"github.com/evergreen-ci/logkeeper/logstore"
+	"github.com/gorilla/mux"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/message"
+	"github.com/mongodb/grip/send"
+	"github.com/urfave/negroni"
+)
+
+// BuildRevision is set at link time (via -ldflags) to the git commit the
+// running binary was built from. It's reported in the startup log line
+// and has no effect on behavior.
+var BuildRevision = "unknown"
+
+// Options configures a logkeeper service instance.
+type Options struct {
+	// URL is this instance's externally-reachable base URL, the base a
+	// caller can pass to GetURL/GetTestURL. The handlers here do not read
+	// it.
+	URL string
+
+	// MaxRequestSize caps the body size of an ingestion request.
+	MaxRequestSize int
+
+	// LogStore is where ingested log lines are persisted. If nil, lines
+	// are kept inline in the logsCollection document, matching
+	// logkeeper's historical behavior.
+	LogStore logstore.LogStore
+
+	// Broker fans newly-ingested lines out to live-tail subscribers. If
+	// nil, ingestion still succeeds but there are no live subscribers to
+	// notify.
+	Broker *livelog.Broker
+
+	// Authorizer gates ingestion and reads of private builds against the
+	// project/builder a request's Principal (see auth.PrincipalFromContext)
+	// is allowed to touch. If nil, every build is treated as public and
+	// every principal as unrestricted, matching logkeeper's historical
+	// anonymous-only behavior.
+	Authorizer *auth.Authorizer
+}
+
+// logKeeper is a configured logkeeper service; New returns one as an
+// http.Handler-producing factory rather than exposing the struct, since
+// nothing outside this package needs to see inside it.
+type logKeeper struct {
+	opts Options
+}
+
+// New constructs a logkeeper service from opts. It also installs
+// opts.LogStore as the store cleanup batch-deletes blobs through; see
+// CurrentLogStore.
+func New(opts Options) *logKeeper {
+	if opts.LogStore != nil {
+		SetLogStore(opts.LogStore)
+	}
+	return &logKeeper{opts: opts}
+}
+
+// NewRouter builds the HTTP router for this service: the ingestion
+// endpoints. Live-tail's GET endpoints are mounted separately by
+// AttachTailRoutes, once the caller has a broker to mount them with.
+func (lk *logKeeper) NewRouter() http.Handler {
+	router := mux.NewRouter()
+	attachIngestRoutes(router, lk.opts)
+	return router
+}
+
+// NewLogger returns the negroni middleware that logs each request: method,
+// path, status, how long it took, and the authenticated principal, if the
+// auth middleware (earlier in the chain) attached one.
+func NewLogger(ctx context.Context) negroni.Handler {
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		start := time.Now()
+		writer := negroni.NewResponseWriter(rw)
+		next(writer, r)
+
+		fields := message.Fields{
+			"message":  "request completed",
+			"method":   r.Method,
+			"path":     r.URL.Path,
+			"status":   writer.Status(),
+			"duration": time.Since(start).String(),
+		}
+		if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+			fields["principal"] = principal.ID
+		}
+		grip.Info(fields)
+	})
+}
+
+// GetSender returns the grip sender logkeeper logs to: a file at path,
+// logging at info level and above by default (see config.Config.LogLevel
+// for changing that after startup).
+func GetSender(ctx context.Context, path string) (send.Sender, error) {
+	return send.NewFileLogger("logkeeper", path, send.LevelInfo{Default: level.Info, Threshold: level.Info})
+}
+
+// BackgroundLogging runs until ctx is canceled. It exists so main.go has a
+// single place to add periodic logging maintenance (e.g. sender flushing)
+// without changing its call site.
+func BackgroundLogging(ctx context.Context) {
+	<-ctx.Done()
+}
+
+// GetHandlerPprof returns the net/http/pprof debug endpoints plus a
+// /metrics endpoint reporting cleanupQueue's job stats as JSON, all
+// served on their own unexported listener so they're never reachable
+// through the public router.
+func GetHandlerPprof(ctx context.Context, cleanupQueue amboy.Queue) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/metrics", metricsHandler(cleanupQueue))
+	return mux
+}
+
+// metricsHandler reports the cleanup queue's pending/running/completed/
+// total job counts as JSON (failed jobs are not counted separately), so
+// operators can scrape them instead of
+// only seeing them in the periodic log line (see reportQueueStats in
+// main.go).
+func metricsHandler(cleanupQueue amboy.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := cleanupQueue.Stats(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Pending   int `json:"cleanup_queue_pending"`
+			Running   int `json:"cleanup_queue_running"`
+			Completed int `json:"cleanup_queue_completed"`
+			Total     int `json:"cleanup_queue_total"`
+		}{
+			Pending:   stats.Pending,
+			Running:   stats.Running,
+			Completed: stats.Completed,
+			Total:     stats.Total,
+		})
+	}
+}
@@ -0,0 +1,15 @@
+// Package db gives logkeeper's packages a single place to resolve a
+// Mongo collection handle from, so none of them need to know the database
+// name or how the client was constructed.
+package db
+
+import (
+	"github.com/evergreen-ci/logkeeper/env"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// C returns the collection named name in the database env.SetDBName
+// configured, using the client env.SetClient configured.
+func C(name string) *mongo.Collection {
+	return env.Client().Database(env.DBName()).Collection(name)
+}
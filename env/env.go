@@ -0,0 +1,108 @@
+// Package env holds the process-wide handles logkeeper's packages share:
+// the Mongo client and database name, a base context, and the cleanup
+// queue. It exists so db.C and friends don't need every caller to thread
+// a *mongo.Client through every function signature.
+package env
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mongodb/amboy"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gopkg.in/mgo.v2"
+)
+
+var (
+	mu sync.RWMutex
+
+	baseCtx      = context.Background()
+	mgoSession   *mgo.Session
+	mongoClient  *mongo.Client
+	dbName       string
+	cleanupQueue amboy.Queue
+)
+
+// SetContext sets the base context Context returns. Packages that need a
+// context outside of a request (e.g. background cleanup) use this one.
+func SetContext(ctx context.Context) {
+	mu.Lock()
+	defer mu.Unlock()
+	baseCtx = ctx
+}
+
+// Context returns the base context set by SetContext, or
+// context.Background() if it was never called.
+func Context() context.Context {
+	mu.RLock()
+	defer mu.RUnlock()
+	return baseCtx
+}
+
+// SetSession records the mgo session main.go dialed at startup.
+func SetSession(session *mgo.Session) error {
+	if session == nil {
+		return errors.New("session is nil")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	mgoSession = session
+	return nil
+}
+
+// Session returns the session set by SetSession.
+func Session() *mgo.Session {
+	mu.RLock()
+	defer mu.RUnlock()
+	return mgoSession
+}
+
+// SetClient records the mongo-driver client db.C uses to reach
+// collections.
+func SetClient(client *mongo.Client) {
+	mu.Lock()
+	defer mu.Unlock()
+	mongoClient = client
+}
+
+// Client returns the client set by SetClient.
+func Client() *mongo.Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	return mongoClient
+}
+
+// SetDBName records the database db.C resolves collections against.
+func SetDBName(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	dbName = name
+}
+
+// DBName returns the name set by SetDBName.
+func DBName() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return dbName
+}
+
+// SetCleanupQueue records the amboy queue cleanup and reconciliation jobs
+// are scheduled onto.
+func SetCleanupQueue(queue amboy.Queue) error {
+	if queue == nil {
+		return errors.New("queue is nil")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	cleanupQueue = queue
+	return nil
+}
+
+// CleanupQueue returns the queue set by SetCleanupQueue, or nil if it was
+// never called.
+func CleanupQueue() amboy.Queue {
+	mu.RLock()
+	defer mu.RUnlock()
+	return cleanupQueue
+}
@@ -0,0 +1,316 @@
+package logkeeper
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/evergreen-ci/logkeeper/auth"
+	"github.com/evergreen-ci/logkeeper/db"
+	"github.com/evergreen-ci/logkeeper/env"
+	"github.com/gorilla/mux"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// attachIngestRoutes mounts the endpoints that create builds/tests and
+// append log lines to them.
+func attachIngestRoutes(router *mux.Router, opts Options) {
+	limit := func(h http.HandlerFunc) http.HandlerFunc {
+		if opts.MaxRequestSize <= 0 {
+			return h
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, int64(opts.MaxRequestSize))
+			h(w, r)
+		}
+	}
+	router.HandleFunc("/build", limit(createBuildHandler(opts))).Methods(http.MethodPost)
+	router.HandleFunc("/build/{id}", getBuildHandler(opts)).Methods(http.MethodGet)
+	router.HandleFunc("/build/{id}/test", limit(createTestHandler(opts))).Methods(http.MethodPost)
+	router.HandleFunc("/build/{id}/log", limit(appendBuildLogHandler(opts))).Methods(http.MethodPost)
+	router.HandleFunc("/build/{id}/test/{tid}/log", limit(appendTestLogHandler(opts))).Methods(http.MethodPost)
+}
+
+type createBuildRequest struct {
+	Info map[string]interface{} `json:"info"`
+}
+
+type createBuildResponse struct {
+	Id string `json:"id"`
+}
+
+// createBuildHandler handles POST /build.
+func createBuildHandler(opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req createBuildRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		project, builder := buildProjectBuilder(req.Info)
+		if !authorized(r, opts, project, builder) {
+			http.Error(w, "not authorized for this project/builder", http.StatusForbidden)
+			return
+		}
+
+		build := LogKeeperBuild{
+			Id:      primitive.NewObjectID().Hex(),
+			Started: time.Now(),
+			Info:    req.Info,
+		}
+		if _, err := db.C(buildsCollection).InsertOne(env.Context(), build); err != nil {
+			grip.Error(errors.Wrap(err, "inserting build"))
+			http.Error(w, "failed to create build", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, createBuildResponse{Id: build.Id})
+	}
+}
+
+// getBuildHandler handles GET /build/{id}. Reads of a private build (see
+// buildIsPrivate) require a principal authorized for its project/builder;
+// public builds stay readable anonymously, as before.
+func getBuildHandler(opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		buildID := mux.Vars(r)["id"]
+
+		build, err := loadBuild(buildID)
+		if err != nil {
+			http.Error(w, "build not found", http.StatusNotFound)
+			return
+		}
+
+		if buildIsPrivate(build.Info) {
+			project, builder := buildProjectBuilder(build.Info)
+			if !authorized(r, opts, project, builder) {
+				http.Error(w, "not authorized for this build", http.StatusForbidden)
+				return
+			}
+		}
+
+		writeJSON(w, http.StatusOK, build)
+	}
+}
+
+// loadBuild fetches a build's metadata document.
+func loadBuild(buildID string) (LogKeeperBuild, error) {
+	var build LogKeeperBuild
+	err := db.C(buildsCollection).FindOne(env.Context(), bson.M{"_id": buildID}).Decode(&build)
+	return build, errors.Wrapf(err, "finding build '%s'", buildID)
+}
+
+// buildProjectBuilder reads the project/builder an ingestion request acts
+// on, or a stored build was created for, out of its Info map.
+func buildProjectBuilder(info map[string]interface{}) (project, builder string) {
+	project, _ = info["project"].(string)
+	builder, _ = info["builder"].(string)
+	return project, builder
+}
+
+// buildIsPrivate reports whether info marks its build as private, gating
+// anonymous reads.
+func buildIsPrivate(info map[string]interface{}) bool {
+	private, _ := info["private"].(bool)
+	return private
+}
+
+// authorized reports whether the request's principal (attached by the
+// auth middleware, if configured; see auth.PrincipalFromContext) may act
+// on the given project/builder. It's always true when opts.Authorizer is
+// nil, matching logkeeper's historical anonymous-only behavior, and always
+// false for an anonymous request when it isn't.
+func authorized(r *http.Request, opts Options, project, builder string) bool {
+	if opts.Authorizer == nil {
+		return true
+	}
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	return opts.Authorizer.Allowed(principal, project, builder)
+}
+
+type createTestRequest struct {
+	Name string `json:"name"`
+}
+
+type createTestResponse struct {
+	Id string `json:"id"`
+}
+
+// createTestHandler handles POST /build/{id}/test.
+func createTestHandler(opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		buildID := mux.Vars(r)["id"]
+
+		build, err := loadBuild(buildID)
+		if err != nil {
+			http.Error(w, "build not found", http.StatusNotFound)
+			return
+		}
+		project, builder := buildProjectBuilder(build.Info)
+		if !authorized(r, opts, project, builder) {
+			http.Error(w, "not authorized for this project/builder", http.StatusForbidden)
+			return
+		}
+
+		var req createTestRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		test := Test{
+			Id:      primitive.NewObjectID(),
+			BuildId: buildID,
+			Name:    req.Name,
+			Started: time.Now(),
+		}
+		if _, err := db.C(testsCollection).InsertOne(env.Context(), test); err != nil {
+			grip.Error(errors.Wrap(err, "inserting test"))
+			http.Error(w, "failed to create test", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, createTestResponse{Id: test.Id.Hex()})
+	}
+}
+
+type appendLogRequest struct {
+	Lines []string `json:"lines"`
+}
+
+// appendBuildLogHandler handles POST /build/{id}/log: lines that belong
+// to the build as a whole, rather than to one of its tests.
+func appendBuildLogHandler(opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		buildID := mux.Vars(r)["id"]
+		appendLog(w, r, opts, buildID, "")
+	}
+}
+
+// appendTestLogHandler handles POST /build/{id}/test/{tid}/log.
+func appendTestLogHandler(opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		appendLog(w, r, opts, vars["id"], vars["tid"])
+	}
+}
+
+// appendLog decodes the posted lines, persists them, and fans them out to
+// any live-tail subscribers for buildID (and testID, if set) before the
+// handler returns, so a tailing client sees them without waiting on a
+// Mongo backfill poll.
+func appendLog(w http.ResponseWriter, r *http.Request, opts Options, buildID, testID string) {
+	build, err := loadBuild(buildID)
+	if err != nil {
+		http.Error(w, "build not found", http.StatusNotFound)
+		return
+	}
+	project, builder := buildProjectBuilder(build.Info)
+	if !authorized(r, opts, project, builder) {
+		http.Error(w, "not authorized for this project/builder", http.StatusForbidden)
+		return
+	}
+
+	var req appendLogRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	seq, err := nextLogSeq(buildID, testID)
+	if err != nil {
+		grip.Error(errors.Wrap(err, "computing next log sequence number"))
+		http.Error(w, "failed to append log", http.StatusInternalServerError)
+		return
+	}
+
+	logDoc := Log{
+		Id:      primitive.NewObjectID(),
+		BuildId: buildID,
+		TestId:  testID,
+		Seq:     seq,
+		Started: time.Now(),
+	}
+	if store := CurrentLogStore(); store != nil {
+		ref, err := store.Put(r.Context(), buildID, testID, seq, req.Lines)
+		if err != nil {
+			grip.Error(errors.Wrap(err, "writing log chunk to log store"))
+			http.Error(w, "failed to append log", http.StatusInternalServerError)
+			return
+		}
+		logDoc.BlobRef = string(ref)
+		logDoc.Bytes = lineBytes(req.Lines)
+	} else {
+		logDoc.Lines = req.Lines
+	}
+	if _, err := db.C(logsCollection).InsertOne(env.Context(), logDoc); err != nil {
+		grip.Error(errors.Wrap(err, "inserting log chunk"))
+		http.Error(w, "failed to append log", http.StatusInternalServerError)
+		return
+	}
+
+	if opts.Broker != nil {
+		now := time.Now().UnixNano()
+		for _, line := range req.Lines {
+			publishLine(opts.Broker, buildID, testID, seq, line, now)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// nextLogSeq returns the sequence number the next chunk appended to
+// buildID (and testID, if set) should use, starting at 0. It's backed by
+// an atomic $inc on a per-build/test counter document in logSeqCollection
+// rather than counting existing chunks, so concurrent appends to the same
+// build/test (normal when many tests log in parallel) can't be handed the
+// same seq.
+func nextLogSeq(buildID, testID string) (int, error) {
+	filter := bson.M{"_id": logSeqKey(buildID, testID)}
+	update := bson.M{"$inc": bson.M{"seq": 1}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+	var prev logSeqCounter
+	err := db.C(logSeqCollection).FindOneAndUpdate(env.Context(), filter, update, opts).Decode(&prev)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "incrementing log sequence counter")
+	}
+	return prev.Seq, nil
+}
+
+// logSeqKey is the logSeqCollection document ID for a build (and test, if
+// set)'s sequence counter.
+func logSeqKey(buildID, testID string) string {
+	if testID == "" {
+		return buildID
+	}
+	return buildID + "/" + testID
+}
+
+// lineBytes sums the byte length of lines, stored on the Log document so
+// callers can report storage usage without fetching the blob.
+func lineBytes(lines []string) int {
+	total := 0
+	for _, line := range lines {
+		total += len(line)
+	}
+	return total
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
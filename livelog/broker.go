@@ -0,0 +1,214 @@
+// Package livelog provides an in-memory broker that lets concurrent
+// consumers tail a build's or test's log lines while they are still being
+// appended to storage. It is intentionally ephemeral: restarting the
+// process drops all subscribers, who are expected to reconnect and
+// backfill from durable storage.
+package livelog
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultRingSize bounds how many lines are buffered per subscriber before
+// the oldest are overwritten. It keeps a slow client from ever blocking a
+// writer.
+const defaultRingSize = 256
+
+// Line is a single log line as streamed to tail subscribers.
+type Line struct {
+	Seq  int       `json:"seq"`
+	Ts   time.Time `json:"ts"`
+	Text string    `json:"text"`
+}
+
+// Subscriber receives lines published to a stream. Send never blocks: if
+// the subscriber's ring buffer is full, the oldest unread line is dropped
+// in favor of the new one.
+type Subscriber struct {
+	lines  chan Line
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newSubscriber(size int) *Subscriber {
+	return &Subscriber{
+		lines:  make(chan Line, size),
+		closed: make(chan struct{}),
+	}
+}
+
+// C returns the channel of lines delivered to this subscriber. It is
+// closed when the subscriber is removed from its broadcaster.
+func (s *Subscriber) C() <-chan Line {
+	return s.lines
+}
+
+func (s *Subscriber) send(line Line) {
+	select {
+	case s.lines <- line:
+		return
+	default:
+	}
+
+	// Ring is full: drop the oldest buffered line to make room rather than
+	// block the writer.
+	select {
+	case <-s.lines:
+	default:
+	}
+	select {
+	case s.lines <- line:
+	default:
+	}
+}
+
+func (s *Subscriber) close() {
+	s.once.Do(func() {
+		close(s.closed)
+		close(s.lines)
+	})
+}
+
+// Broadcaster fans a single stream's lines out to its current subscribers.
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[*Subscriber]struct{}
+	ringSize    int
+}
+
+func newBroadcaster(ringSize int) *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[*Subscriber]struct{}),
+		ringSize:    ringSize,
+	}
+}
+
+// Subscribe registers a new subscriber and returns it. Callers must call
+// Unsubscribe when they are done reading.
+func (b *Broadcaster) Subscribe() *Subscriber {
+	sub := newSubscriber(b.ringSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel.
+func (b *Broadcaster) Unsubscribe(sub *Subscriber) {
+	b.mu.Lock()
+	_, ok := b.subscribers[sub]
+	delete(b.subscribers, sub)
+	b.mu.Unlock()
+
+	if ok {
+		sub.close()
+	}
+}
+
+// Publish fans a line out to every current subscriber.
+func (b *Broadcaster) Publish(line Line) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		sub.send(line)
+	}
+}
+
+// closeAll closes every subscriber, used when the stream is torn down.
+func (b *Broadcaster) closeAll() {
+	b.mu.Lock()
+	subs := make([]*Subscriber, 0, len(b.subscribers))
+	for sub := range b.subscribers {
+		subs = append(subs, sub)
+	}
+	b.subscribers = make(map[*Subscriber]struct{})
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.close()
+	}
+}
+
+func (b *Broadcaster) subscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// Broker owns one Broadcaster per build or test stream, keyed by whatever
+// id the caller uses to identify the stream (a build id, or a build id and
+// test id joined by the caller).
+type Broker struct {
+	mu           sync.Mutex
+	broadcasters map[string]*Broadcaster
+	ringSize     int
+}
+
+// NewBroker returns an empty Broker. A ringSize of 0 uses defaultRingSize.
+func NewBroker(ringSize int) *Broker {
+	if ringSize <= 0 {
+		ringSize = defaultRingSize
+	}
+	return &Broker{
+		broadcasters: make(map[string]*Broadcaster),
+		ringSize:     ringSize,
+	}
+}
+
+// Publish fans a line out to subscribers of streamID, creating the stream
+// if it does not already have subscribers.
+func (br *Broker) Publish(streamID string, line Line) {
+	br.getOrCreate(streamID).Publish(line)
+}
+
+// Subscribe returns a Subscriber for streamID. Callers must pass the
+// returned Subscriber to Unsubscribe when finished.
+func (br *Broker) Subscribe(streamID string) *Subscriber {
+	return br.getOrCreate(streamID).Subscribe()
+}
+
+// Unsubscribe removes sub from streamID's broadcaster and prunes the
+// broadcaster if it has no remaining subscribers.
+func (br *Broker) Unsubscribe(streamID string, sub *Subscriber) {
+	br.mu.Lock()
+	b, ok := br.broadcasters[streamID]
+	br.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	b.Unsubscribe(sub)
+
+	br.mu.Lock()
+	if b.subscriberCount() == 0 && br.broadcasters[streamID] == b {
+		delete(br.broadcasters, streamID)
+	}
+	br.mu.Unlock()
+}
+
+func (br *Broker) getOrCreate(streamID string) *Broadcaster {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	b, ok := br.broadcasters[streamID]
+	if !ok {
+		b = newBroadcaster(br.ringSize)
+		br.broadcasters[streamID] = b
+	}
+	return b
+}
+
+// Close tears down every stream, closing each subscriber so that callers
+// streaming to clients can send a clean close frame.
+func (br *Broker) Close() {
+	br.mu.Lock()
+	broadcasters := br.broadcasters
+	br.broadcasters = make(map[string]*Broadcaster)
+	br.mu.Unlock()
+
+	for _, b := range broadcasters {
+		b.closeAll()
+	}
+}
@@ -0,0 +1,75 @@
+package livelog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeAndPublish(t *testing.T) {
+	assert := assert.New(t)
+
+	br := NewBroker(4)
+	sub := br.Subscribe("build-1")
+	defer br.Unsubscribe("build-1", sub)
+
+	br.Publish("build-1", Line{Seq: 1, Text: "hello"})
+	select {
+	case line := <-sub.C():
+		assert.Equal(1, line.Seq)
+		assert.Equal("hello", line.Text)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for published line")
+	}
+}
+
+func TestSlowSubscriberDropsOldestRatherThanBlocking(t *testing.T) {
+	assert := assert.New(t)
+
+	br := NewBroker(2)
+	sub := br.Subscribe("build-1")
+	defer br.Unsubscribe("build-1", sub)
+
+	for i := 0; i < 5; i++ {
+		br.Publish("build-1", Line{Seq: i})
+	}
+
+	first := <-sub.C()
+	second := <-sub.C()
+	assert.Equal(3, first.Seq)
+	assert.Equal(4, second.Seq)
+}
+
+func TestUnsubscribeClosesChannel(t *testing.T) {
+	assert := assert.New(t)
+
+	br := NewBroker(0)
+	sub := br.Subscribe("test-1")
+	br.Unsubscribe("test-1", sub)
+
+	_, ok := <-sub.C()
+	assert.False(ok)
+}
+
+func TestCloseClosesAllSubscribers(t *testing.T) {
+	assert := assert.New(t)
+
+	br := NewBroker(0)
+	subA := br.Subscribe("build-1")
+	subB := br.Subscribe("build-2")
+
+	br.Close()
+
+	_, okA := <-subA.C()
+	_, okB := <-subB.C()
+	assert.False(okA)
+	assert.False(okB)
+}
+
+func TestPublishWithNoSubscribersIsANoop(t *testing.T) {
+	br := NewBroker(0)
+	assert.NotPanics(t, func() {
+		br.Publish("nobody-listening", Line{Seq: 1})
+	})
+}
@@ -0,0 +1,63 @@
+package logstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// memoryStore is an in-memory LogStore used only by tests, standing in for
+// a fakes3/minio fixture so LogStore's conformance tests don't need live
+// infrastructure. It implements ListableStore so reconciliation logic can
+// be exercised too.
+type memoryStore struct {
+	mu    sync.Mutex
+	blobs map[BlobRef][]byte
+	seq   int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{blobs: make(map[BlobRef][]byte)}
+}
+
+func (s *memoryStore) Put(ctx context.Context, buildID, testID string, seq int, lines []string) (BlobRef, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	ref := BlobRef(blobKey(buildID, testID, s.seq))
+	s.blobs[ref] = encodeNDJSON(lines)
+	return ref, nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, ref BlobRef) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.blobs[ref]
+	if !ok {
+		return nil, errors.Errorf("no blob '%s'", ref)
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, refs ...BlobRef) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ref := range refs {
+		delete(s.blobs, ref)
+	}
+	return nil
+}
+
+func (s *memoryStore) List(ctx context.Context) ([]BlobRef, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	refs := make([]BlobRef, 0, len(s.blobs))
+	for ref := range s.blobs {
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
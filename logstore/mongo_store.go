@@ -0,0 +1,97 @@
+package logstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+
+	"github.com/evergreen-ci/logkeeper/db"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// blobsCollection holds the raw bytes for MongoStore blobs, separate from
+// logsCollection, which after this change stores metadata only.
+const blobsCollection = "log_blobs"
+
+type blobDoc struct {
+	Id   primitive.ObjectID `bson:"_id"`
+	Data []byte             `bson:"data"`
+}
+
+// MongoStore is the original backend: it keeps blobs inline in Mongo. It
+// exists so deployments can keep running without standing up object
+// storage, and so the object-storage backend has something to be tested
+// against behind the same interface.
+type MongoStore struct{}
+
+// NewMongoStore returns a LogStore backed by the blobsCollection.
+func NewMongoStore() *MongoStore {
+	return &MongoStore{}
+}
+
+func (s *MongoStore) Put(ctx context.Context, buildID, testID string, seq int, lines []string) (BlobRef, error) {
+	id := primitive.NewObjectID()
+	doc := blobDoc{Id: id, Data: encodeNDJSON(lines)}
+
+	if _, err := db.C(blobsCollection).InsertOne(ctx, doc); err != nil {
+		return "", errors.Wrap(err, "inserting log blob")
+	}
+	return BlobRef(id.Hex()), nil
+}
+
+func (s *MongoStore) Get(ctx context.Context, ref BlobRef) (io.ReadCloser, error) {
+	id, err := primitive.ObjectIDFromHex(string(ref))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid mongo blob ref '%s'", ref)
+	}
+
+	var doc blobDoc
+	if err := db.C(blobsCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&doc); err != nil {
+		return nil, errors.Wrapf(err, "finding log blob '%s'", ref)
+	}
+	return ioutil.NopCloser(bytes.NewReader(doc.Data)), nil
+}
+
+// List returns the BlobRef of every document in blobsCollection.
+func (s *MongoStore) List(ctx context.Context) ([]BlobRef, error) {
+	cursor, err := db.C(blobsCollection).Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "listing log blobs")
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		Id primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, errors.Wrap(err, "decoding log blob ids")
+	}
+
+	refs := make([]BlobRef, 0, len(docs))
+	for _, doc := range docs {
+		refs = append(refs, BlobRef(doc.Id.Hex()))
+	}
+	return refs, nil
+}
+
+func (s *MongoStore) Delete(ctx context.Context, refs ...BlobRef) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(refs))
+	for _, ref := range refs {
+		id, err := primitive.ObjectIDFromHex(string(ref))
+		if err != nil {
+			return errors.Wrapf(err, "invalid mongo blob ref '%s'", ref)
+		}
+		ids = append(ids, id)
+	}
+
+	_, err := db.C(blobsCollection).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return errors.Wrap(err, "deleting log blobs")
+}
@@ -0,0 +1,51 @@
+package logstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// lineRecord is the on-the-wire representation shared by every LogStore
+// backend so that blobs written by one implementation can be read back by
+// another during a migration between them.
+type lineRecord struct {
+	Text string `json:"text"`
+}
+
+// encodeNDJSON renders lines as newline-delimited JSON records.
+func encodeNDJSON(lines []string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, line := range lines {
+		// Encoder.Encode errors only on unsupported types, which a string
+		// never triggers.
+		_ = enc.Encode(lineRecord{Text: line})
+	}
+	return buf.Bytes()
+}
+
+// decodeNDJSON parses newline-delimited JSON records back into lines.
+func decodeNDJSON(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec lineRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, errors.Wrap(err, "decoding ndjson line record")
+		}
+		lines = append(lines, rec.Text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "scanning ndjson blob")
+	}
+	return lines, nil
+}
@@ -0,0 +1,28 @@
+package logstore
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeNDJSONRoundTrips(t *testing.T) {
+	lines := []string{"first line", "", "third line with \"quotes\""}
+
+	decoded, err := decodeNDJSON(bytes.NewReader(encodeNDJSON(lines)))
+	require.NoError(t, err)
+	assert.Equal(t, lines, decoded)
+}
+
+func TestDecodeNDJSONSkipsBlankLines(t *testing.T) {
+	decoded, err := decodeNDJSON(bytes.NewReader([]byte(`{"text":"a"}` + "\n\n" + `{"text":"b"}` + "\n")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, decoded)
+}
+
+func TestBlobKeyIncludesTestIDOnlyWhenSet(t *testing.T) {
+	assert.Equal(t, "builds/build1/4.ndjson", blobKey("build1", "", 4))
+	assert.Equal(t, "builds/build1/tests/test1/4.ndjson", blobKey("build1", "test1", 4))
+}
@@ -0,0 +1,136 @@
+package logstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// maxDeleteBatch is the largest number of keys S3 (and GCS's S3-compatible
+// XML API) will delete in a single request.
+const maxDeleteBatch = 1000
+
+// ObjectStoreOptions configures an ObjectStore. Endpoint and ForcePathStyle
+// are set for S3-compatible providers (GCS, minio) that don't use virtual
+// hosted-style addressing.
+type ObjectStoreOptions struct {
+	Bucket         string
+	Endpoint       string
+	Region         string
+	ForcePathStyle bool
+}
+
+// ObjectStore is a LogStore backed by an S3-compatible object store. Blobs
+// are written as newline-delimited JSON objects keyed by
+// builds/{buildID}/tests/{testID}/{seq}.ndjson (build-level chunks omit the
+// tests/{testID} segment: builds/{buildID}/{seq}.ndjson).
+type ObjectStore struct {
+	bucket string
+	client *s3.S3
+}
+
+// NewObjectStore returns a LogStore that writes blobs to opts.Bucket.
+func NewObjectStore(opts ObjectStoreOptions) (*ObjectStore, error) {
+	config := aws.NewConfig().WithRegion(opts.Region)
+	if opts.Endpoint != "" {
+		config = config.WithEndpoint(opts.Endpoint).WithS3ForcePathStyle(opts.ForcePathStyle)
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating AWS session for log object store")
+	}
+
+	return &ObjectStore{
+		bucket: opts.Bucket,
+		client: s3.New(sess),
+	}, nil
+}
+
+func blobKey(buildID, testID string, seq int) string {
+	if testID == "" {
+		return fmt.Sprintf("builds/%s/%d.ndjson", buildID, seq)
+	}
+	return fmt.Sprintf("builds/%s/tests/%s/%d.ndjson", buildID, testID, seq)
+}
+
+func (s *ObjectStore) Put(ctx context.Context, buildID, testID string, seq int, lines []string) (BlobRef, error) {
+	key := blobKey(buildID, testID, seq)
+
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(encodeNDJSON(lines)),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "putting log blob '%s'", key)
+	}
+	return BlobRef(key), nil
+}
+
+func (s *ObjectStore) Get(ctx context.Context, ref BlobRef) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(string(ref)),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting log blob '%s'", ref)
+	}
+	return out.Body, nil
+}
+
+// List returns the key of every object under the builds/ prefix this
+// ObjectStore writes blobs to.
+func (s *ObjectStore) List(ctx context.Context) ([]BlobRef, error) {
+	var refs []BlobRef
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String("builds/"),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			refs = append(refs, BlobRef(aws.StringValue(obj.Key)))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing log blobs")
+	}
+	return refs, nil
+}
+
+func (s *ObjectStore) Delete(ctx context.Context, refs ...BlobRef) error {
+	catcher := grip.NewBasicCatcher()
+
+	for start := 0; start < len(refs); start += maxDeleteBatch {
+		end := start + maxDeleteBatch
+		if end > len(refs) {
+			end = len(refs)
+		}
+		catcher.Add(s.deleteBatch(ctx, refs[start:end]))
+	}
+	return catcher.Resolve()
+}
+
+func (s *ObjectStore) deleteBatch(ctx context.Context, refs []BlobRef) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	objects := make([]*s3.ObjectIdentifier, 0, len(refs))
+	for _, ref := range refs {
+		objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(string(ref))})
+	}
+
+	_, err := s.client.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(s.bucket),
+		Delete: &s3.Delete{Objects: objects},
+	})
+	return errors.Wrap(err, "batch-deleting log blobs")
+}
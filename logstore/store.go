@@ -0,0 +1,51 @@
+// Package logstore abstracts where log line payloads actually live.
+// Mongo historically stored whole chunks inline; LogStore lets that be
+// swapped for an object-storage backend while Mongo keeps only metadata.
+package logstore
+
+import (
+	"context"
+	"io"
+)
+
+// BlobRef identifies a stored chunk of log lines within a LogStore
+// implementation. Its meaning is backend-specific: the Mongo backend
+// treats it as a document id, the object-storage backend as a key.
+type BlobRef string
+
+// DecodeLines reads and parses a blob written by Put back into lines. Call
+// sites reading a chunk back (e.g. tail backfill) use this instead of
+// parsing the NDJSON wire format themselves.
+func DecodeLines(r io.Reader) ([]string, error) {
+	return decodeNDJSON(r)
+}
+
+// LogStore persists and retrieves chunks of log lines for a build/test at
+// a given sequence number. Implementations must be safe for concurrent
+// use.
+type LogStore interface {
+	// Put stores lines for testID (empty for a build-level chunk) at seq
+	// and returns a reference that can later be passed to Get or Delete.
+	Put(ctx context.Context, buildID, testID string, seq int, lines []string) (BlobRef, error)
+
+	// Get returns a reader over the raw bytes stored at ref. Callers must
+	// close the returned ReadCloser.
+	Get(ctx context.Context, ref BlobRef) (io.ReadCloser, error)
+
+	// Delete removes the blobs referenced by refs. Implementations should
+	// batch internally where their backend imposes a limit on bulk
+	// deletes, and should treat deleting an already-absent blob as
+	// success.
+	Delete(ctx context.Context, refs ...BlobRef) error
+}
+
+// ListableStore is implemented by LogStore backends that can enumerate
+// every blob they hold. reconcileBlobsJob uses it to find blobs with no
+// corresponding logs metadata document, the orphan direction Get/Put alone
+// can't detect. Not every LogStore need implement it.
+type ListableStore interface {
+	LogStore
+
+	// List returns every BlobRef currently stored.
+	List(ctx context.Context) ([]BlobRef, error)
+}
@@ -0,0 +1,68 @@
+package logstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLogStoreConformance exercises every LogStore implementation behind
+// the same interface, so swapping backends (Mongo, S3, or this package's
+// in-memory test fixture) can't silently change Put/Get/Delete semantics.
+// MongoStore and ObjectStore need live infrastructure this environment
+// doesn't have; memoryStore stands in for a fakes3/minio fixture so the
+// contract itself is still covered without it.
+func TestLogStoreConformance(t *testing.T) {
+	stores := map[string]LogStore{
+		"memory": newMemoryStore(),
+	}
+
+	for name, store := range stores {
+		store := store
+		t.Run(name, func(t *testing.T) {
+			testPutGetRoundTrips(t, store)
+			testDeleteRemovesBlob(t, store)
+			testDeleteIsIdempotent(t, store)
+		})
+	}
+}
+
+func testPutGetRoundTrips(t *testing.T, store LogStore) {
+	ctx := context.Background()
+	lines := []string{"first line", "second line"}
+
+	ref, err := store.Put(ctx, "build1", "", 0, lines)
+	require.NoError(t, err)
+
+	reader, err := store.Get(ctx, ref)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	decoded, err := DecodeLines(reader)
+	require.NoError(t, err)
+	assert.Equal(t, lines, decoded)
+}
+
+func testDeleteRemovesBlob(t *testing.T, store LogStore) {
+	ctx := context.Background()
+
+	ref, err := store.Put(ctx, "build1", "", 0, []string{"line"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Delete(ctx, ref))
+
+	_, err = store.Get(ctx, ref)
+	assert.Error(t, err)
+}
+
+func testDeleteIsIdempotent(t *testing.T, store LogStore) {
+	ctx := context.Background()
+
+	ref, err := store.Put(ctx, "build1", "", 0, []string{"line"})
+	require.NoError(t, err)
+	require.NoError(t, store.Delete(ctx, ref))
+
+	assert.NoError(t, store.Delete(ctx, ref))
+}
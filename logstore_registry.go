@@ -0,0 +1,30 @@
+package logkeeper
+
+import (
+	"sync"
+
+	"github.com/evergreen-ci/logkeeper/logstore"
+)
+
+var (
+	logStoreMu sync.RWMutex
+	logStore   logstore.LogStore
+)
+
+// SetLogStore installs store as the LogStore ingestion handlers write
+// lines through and cleanup deletes blobs through. New calls this
+// automatically for Options.LogStore; it's exported separately so tests
+// can install a fake without going through New.
+func SetLogStore(store logstore.LogStore) {
+	logStoreMu.Lock()
+	defer logStoreMu.Unlock()
+	logStore = store
+}
+
+// CurrentLogStore returns the store set by SetLogStore, or nil if none has
+// been configured (historical inline-in-Mongo behavior).
+func CurrentLogStore() logstore.LogStore {
+	logStoreMu.RLock()
+	defer logStoreMu.RUnlock()
+	return logStore
+}
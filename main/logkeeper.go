@@ -13,9 +13,15 @@ import (
 	"time"
 
 	"github.com/evergreen-ci/logkeeper"
+	"github.com/evergreen-ci/logkeeper/auth"
+	"github.com/evergreen-ci/logkeeper/config"
 	"github.com/evergreen-ci/logkeeper/env"
+	"github.com/evergreen-ci/logkeeper/livelog"
+	"github.com/evergreen-ci/logkeeper/logstore"
 	"github.com/evergreen-ci/logkeeper/units"
 	gorillaCtx "github.com/gorilla/context"
+	"github.com/gorilla/mux"
+	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/pool"
 	"github.com/mongodb/amboy/queue"
 	"github.com/mongodb/grip"
@@ -38,8 +44,60 @@ func main() {
 	logPath := flag.String("logpath", "logkeeperapp.log", "path to log file")
 	maxRequestSize := flag.Int("maxRequestSize", 1024*1024*32,
 		"maximum size for a request in bytes, defaults to 32 MB (in bytes)")
+	logStoreType := flag.String("logStore", "mongo", "backend for log payloads: 'mongo' or 's3'")
+	s3Bucket := flag.String("s3Bucket", "", "bucket to use when logStore=s3")
+	s3Endpoint := flag.String("s3Endpoint", "", "S3-compatible endpoint to use when logStore=s3 (leave empty for AWS)")
+	s3Region := flag.String("s3Region", "us-east-1", "region to use when logStore=s3")
+	queueType := flag.String("queueType", "local", "cleanup queue backend: 'local' or 'remote' (MongoDB-backed, for multiple replicas)")
+	authConfigPath := flag.String("authConfig", "", "path to an auth config file; empty means anonymous access (current default behavior)")
+	configPath := flag.String("config", "", "path to a YAML config file covering these flags plus queue tuning and cleanup settings; "+
+		"flags explicitly passed on the command line override the file")
 	flag.Parse()
 
+	explicitFlags := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicitFlags[f.Name] = true })
+
+	fileConfig := config.Default()
+	if *configPath != "" {
+		loaded, err := config.LoadConfig(*configPath)
+		grip.EmergencyFatal(errors.Wrap(err, "loading config file"))
+		fileConfig = loaded
+	}
+
+	if !explicitFlags["port"] {
+		*httpPort = fileConfig.Port
+	}
+	if !explicitFlags["dbhost"] {
+		*dbHost = fileConfig.DBHost
+	}
+	if !explicitFlags["rsName"] {
+		*rsName = fileConfig.RSName
+	}
+	if !explicitFlags["logpath"] {
+		*logPath = fileConfig.LogPath
+	}
+	if !explicitFlags["maxRequestSize"] {
+		*maxRequestSize = fileConfig.MaxRequestSize
+	}
+	if !explicitFlags["logStore"] {
+		*logStoreType = fileConfig.LogStoreType
+	}
+	if !explicitFlags["s3Bucket"] {
+		*s3Bucket = fileConfig.S3Bucket
+	}
+	if !explicitFlags["s3Endpoint"] {
+		*s3Endpoint = fileConfig.S3Endpoint
+	}
+	if !explicitFlags["s3Region"] {
+		*s3Region = fileConfig.S3Region
+	}
+	if !explicitFlags["queueType"] {
+		*queueType = fileConfig.QueueType
+	}
+	if !explicitFlags["authConfig"] {
+		*authConfigPath = fileConfig.AuthConfigPath
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -49,6 +107,8 @@ func main() {
 
 	grip.EmergencyFatal(grip.SetSender(sender))
 
+	grip.EmergencyFatal(errors.Wrap(fileConfig.ApplyReloadable(), "applying config at startup"))
+
 	dialInfo := mgo.DialInfo{
 		Addrs: strings.Split(*dbHost, ","),
 	}
@@ -61,25 +121,56 @@ func main() {
 	grip.EmergencyFatal(err)
 	grip.EmergencyFatal(env.SetSession(session))
 
-	cleanupQueue := queue.NewLocalLimitedSize(logkeeper.AmboyWorkers, logkeeper.QueueSizeCap)
-	runner, err := pool.NewMovingAverageRateLimitedWorkers(logkeeper.AmboyWorkers, logkeeper.AmboyTargetNumJobs, logkeeper.AmboyInterval, cleanupQueue)
-	grip.EmergencyFatal(errors.Wrap(err, "problem constructing worker pool"))
-	grip.EmergencyFatal(cleanupQueue.SetRunner(runner))
+	cleanupQueue, err := buildCleanupQueue(ctx, *queueType, session)
+	grip.EmergencyFatal(errors.Wrap(err, "problem constructing cleanup queue"))
 	grip.EmergencyFatal(cleanupQueue.Start(ctx))
 	grip.EmergencyFatal(env.SetCleanupQueue(cleanupQueue))
 
+	go reportQueueStats(ctx, cleanupQueue, time.Minute)
+	if *configPath != "" {
+		go watchForSIGHUP(ctx, *configPath, cleanupQueue)
+	}
+
+	logStore, err := buildLogStore(*logStoreType, *s3Bucket, *s3Endpoint, *s3Region)
+	grip.EmergencyFatal(errors.Wrap(err, "constructing log store"))
+	units.SetReconcileStore(logStore)
+
 	grip.EmergencyFatal(units.StartCrons(ctx, cleanupQueue))
 
-	lk := logkeeper.New(logkeeper.Options{
+	tailBroker := livelog.NewBroker(0)
+
+	var authMiddleware *auth.Middleware
+	if *authConfigPath != "" {
+		authConfig, err := auth.LoadConfig(*authConfigPath)
+		grip.EmergencyFatal(errors.Wrap(err, "loading auth config"))
+		authMiddleware, err = authConfig.BuildMiddleware(ctx)
+		grip.EmergencyFatal(errors.Wrap(err, "building auth middleware"))
+	}
+
+	opts := logkeeper.Options{
 		URL:            fmt.Sprintf("http://localhost:%v", *httpPort),
 		MaxRequestSize: *maxRequestSize,
-	})
+		LogStore:       logStore,
+		Broker:         tailBroker,
+	}
+	if authMiddleware != nil {
+		opts.Authorizer = authMiddleware.Authorizer()
+	}
+	lk := logkeeper.New(opts)
 	env.SetDBName(dbName)
 	go logkeeper.BackgroundLogging(ctx)
 
 	catcher := grip.NewCatcher()
 	router := lk.NewRouter()
+	if muxRouter, ok := router.(*mux.Router); ok {
+		logkeeper.AttachTailRoutes(muxRouter, opts, tailBroker)
+	} else {
+		grip.Warning("router is not a *mux.Router, live-tail endpoints were not mounted")
+	}
 	n := negroni.New()
+	if authMiddleware != nil {
+		n.Use(authMiddleware)
+	}
 	n.Use(logkeeper.NewLogger(ctx))              // includes recovery and logging
 	n.Use(negroni.NewStatic(http.Dir("public"))) // part of negroni Classic settings
 	n.UseHandler(gorillaCtx.ClearHandler(router))
@@ -93,7 +184,7 @@ func main() {
 		catcher.Add(listenServeAndHandleErrs(lkService))
 	}()
 
-	pprofService := getService("127.0.0.1:2285", logkeeper.GetHandlerPprof(ctx))
+	pprofService := getService("127.0.0.1:2285", logkeeper.GetHandlerPprof(ctx, cleanupQueue))
 	serviceWait.Add(1)
 	go func() {
 		defer recovery.LogStackTraceAndContinue("pprof service")
@@ -103,7 +194,7 @@ func main() {
 
 	gracefulWait := &sync.WaitGroup{}
 	gracefulWait.Add(1)
-	go gracefulShutdownForSIGTERM(ctx, []*http.Server{lkService, pprofService}, gracefulWait, catcher)
+	go gracefulShutdownForSIGTERM(ctx, []*http.Server{lkService, pprofService}, gracefulWait, catcher, tailBroker, cleanupQueue)
 
 	serviceWait.Wait()
 
@@ -113,6 +204,155 @@ func main() {
 	grip.EmergencyFatal(catcher.Resolve())
 }
 
+// buildCleanupQueue constructs the queue that units.StartCrons drains.
+// "local" pins all cleanup work to this process, as before. "remote"
+// shares a MongoDB-backed queue across every logkeeper replica pointed at
+// the same DB, so cleanup jobs are cooperatively drained instead of
+// duplicated.
+func buildCleanupQueue(ctx context.Context, queueType string, session *mgo.Session) (amboy.Queue, error) {
+	switch queueType {
+	case "", "local":
+		q := queue.NewLocalLimitedSize(logkeeper.AmboyWorkers, logkeeper.QueueSizeCap)
+		runner, err := pool.NewMovingAverageRateLimitedWorkers(logkeeper.AmboyWorkers, logkeeper.AmboyTargetNumJobs, logkeeper.AmboyInterval, q)
+		if err != nil {
+			return nil, errors.Wrap(err, "constructing worker pool")
+		}
+		if err := q.SetRunner(runner); err != nil {
+			return nil, errors.Wrap(err, "setting queue runner")
+		}
+		return q, nil
+	case "remote":
+		q := queue.NewRemoteUnordered(logkeeper.AmboyWorkers)
+		driver, err := queue.OpenNewMgoDriver(ctx, dbName+".jobs", queue.MongoDBOptions{DB: dbName}, session)
+		if err != nil {
+			return nil, errors.Wrap(err, "opening MongoDB queue driver")
+		}
+		if err := q.SetDriver(driver); err != nil {
+			return nil, errors.Wrap(err, "attaching MongoDB queue driver")
+		}
+		return q, nil
+	default:
+		return nil, errors.Errorf("unrecognized queueType '%s'", queueType)
+	}
+}
+
+// watchForSIGHUP re-reads the config file at configPath on every SIGHUP
+// and applies whatever it can without a restart: the cleanup queue's
+// worker pool size, the cleanup batch size and cutoff, and the log
+// level. Fields that can't be changed live (listen address, DB host) are
+// left untouched; a warning is logged naming them if the reload would
+// have changed them.
+func watchForSIGHUP(ctx context.Context, configPath string, cleanupQueue amboy.Queue) {
+	defer recovery.LogStackTraceAndContinue("config hot reload")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigChan:
+			applyConfigReload(configPath, cleanupQueue)
+		}
+	}
+}
+
+func applyConfigReload(configPath string, cleanupQueue amboy.Queue) {
+	newConfig, err := config.LoadConfig(configPath)
+	if err != nil {
+		grip.Error(errors.Wrap(err, "reloading config on SIGHUP, keeping current settings"))
+		return
+	}
+
+	if err := newConfig.ApplyReloadable(); err != nil {
+		grip.Error(errors.Wrap(err, "applying reloadable config settings"))
+	}
+
+	if err := resizeWorkerPool(cleanupQueue, newConfig.AmboyWorkers); err != nil {
+		grip.Warning(errors.Wrap(err, "could not resize cleanup worker pool on reload"))
+	}
+
+	// The flag values hold the settings in effect since startup.
+	for field, changed := range map[string]bool{
+		"port":    flag.Lookup("port").Value.String() != fmt.Sprint(newConfig.Port),
+		"db_host": flag.Lookup("dbhost").Value.String() != newConfig.DBHost,
+	} {
+		if changed {
+			grip.Warning(message.Fields{
+				"message": "config reload changed a field that cannot be applied without a restart; leaving it unchanged",
+				"field":   field,
+			})
+		}
+	}
+
+	grip.Info(message.Fields{
+		"message": "applied config reload from SIGHUP",
+		"note":    "listen address and DB host are not reloadable; restart to change them",
+	})
+}
+
+// poolResizer matches amboy worker pools (e.g. the one backing
+// pool.NewMovingAverageRateLimitedWorkers) that support changing their
+// size without being rebuilt.
+type poolResizer interface {
+	SetSize(int) error
+}
+
+func resizeWorkerPool(q amboy.Queue, size int) error {
+	runner := q.Runner()
+	if resizer, ok := runner.(poolResizer); ok {
+		return resizer.SetSize(size)
+	}
+	return errors.New("queue's runner does not support resizing; restart to change amboy_workers")
+}
+
+// reportQueueStats logs cleanup queue throughput on the given interval so
+// operators can see pending/running/completed/total counts without
+// instrumenting every job individually. Failed jobs are not counted
+// separately.
+func reportQueueStats(ctx context.Context, q amboy.Queue, interval time.Duration) {
+	defer recovery.LogStackTraceAndContinue("queue stats reporter")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := q.Stats(ctx)
+			grip.Info(message.Fields{
+				"message":   "cleanup queue stats",
+				"pending":   stats.Pending,
+				"running":   stats.Running,
+				"completed": stats.Completed,
+				"total":     stats.Total,
+			})
+		}
+	}
+}
+
+func buildLogStore(storeType, bucket, endpoint, region string) (logstore.LogStore, error) {
+	switch storeType {
+	case "", "mongo":
+		return logstore.NewMongoStore(), nil
+	case "s3":
+		if bucket == "" {
+			return nil, errors.New("s3Bucket is required when logStore=s3")
+		}
+		return logstore.NewObjectStore(logstore.ObjectStoreOptions{
+			Bucket:         bucket,
+			Endpoint:       endpoint,
+			Region:         region,
+			ForcePathStyle: endpoint != "",
+		})
+	default:
+		return nil, errors.Errorf("unrecognized logStore '%s'", storeType)
+	}
+}
+
 func listenServeAndHandleErrs(s *http.Server) error {
 	if s == nil {
 		return errors.New("no server defined")
@@ -142,7 +382,7 @@ func getService(addr string, n http.Handler) *http.Server {
 
 }
 
-func gracefulShutdownForSIGTERM(ctx context.Context, servers []*http.Server, gracefulWait *sync.WaitGroup, catcher grip.Catcher) {
+func gracefulShutdownForSIGTERM(ctx context.Context, servers []*http.Server, gracefulWait *sync.WaitGroup, catcher grip.Catcher, tailBroker *livelog.Broker, cleanupQueue amboy.Queue) {
 	defer recovery.LogStackTraceAndContinue("graceful shutdown")
 	defer gracefulWait.Done()
 	sigChan := make(chan os.Signal, len(servers))
@@ -150,6 +390,7 @@ func gracefulShutdownForSIGTERM(ctx context.Context, servers []*http.Server, gra
 	<-sigChan
 
 	grip.Info("received SIGTERM, terminating web service")
+	tailBroker.Close()
 	wg := sync.WaitGroup{}
 	for _, s := range servers {
 		if s == nil {
@@ -163,4 +404,9 @@ func gracefulShutdownForSIGTERM(ctx context.Context, servers []*http.Server, gra
 		}(s)
 	}
 	wg.Wait()
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelDrain()
+	amboy.WaitInterval(drainCtx, cleanupQueue, 100*time.Millisecond)
+	cleanupQueue.Close(drainCtx)
 }
@@ -0,0 +1,59 @@
+package logkeeper
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Collection names for the four document types logkeeper stores in
+// Mongo. logstore.MongoStore stores blob bytes separately, in
+// blobsCollection.
+const (
+	buildsCollection = "builds"
+	testsCollection  = "tests"
+	logsCollection   = "logs"
+	logSeqCollection = "log_seqs"
+)
+
+// LogKeeperBuild is the metadata document for one ingested build: when it
+// started, whether it's failed (which exempts it from cleanup), and the
+// caller-supplied Info used both to render the build and to authorize
+// access to it (see buildProjectBuilder).
+type LogKeeperBuild struct {
+	Id      string                 `bson:"_id"`
+	Started time.Time              `bson:"started"`
+	Failed  bool                   `bson:"failed"`
+	Info    map[string]interface{} `bson:"info"`
+}
+
+// Test is the metadata document for one test within a build.
+type Test struct {
+	Id      primitive.ObjectID `bson:"_id"`
+	BuildId string             `bson:"build_id"`
+	Name    string             `bson:"name,omitempty"`
+	Started time.Time          `bson:"started"`
+}
+
+// Log is one chunk of ingested log lines. TestId is empty for a
+// build-level chunk. Lines holds the text inline; BlobRef and Bytes are
+// set instead when a logstore.LogStore is configured, in which case Lines
+// is empty and the text lives wherever BlobRef points.
+type Log struct {
+	Id      primitive.ObjectID `bson:"_id,omitempty"`
+	BuildId string             `bson:"build_id"`
+	TestId  string             `bson:"test_id,omitempty"`
+	Seq     int                `bson:"seq"`
+	Started time.Time          `bson:"started,omitempty"`
+	Lines   []string           `bson:"lines,omitempty"`
+	BlobRef string             `bson:"blob_ref,omitempty"`
+	Bytes   int                `bson:"bytes,omitempty"`
+}
+
+// logSeqCounter backs nextLogSeq's atomic per-build/test counter in
+// logSeqCollection: one document per buildID (or buildID/testID), holding
+// the next sequence number to hand out.
+type logSeqCounter struct {
+	Id  string `bson:"_id"`
+	Seq int    `bson:"seq"`
+}
@@ -0,0 +1,48 @@
+package logkeeper
+
+import (
+	"sync"
+	"time"
+)
+
+// runtimeConfigMu guards the package vars below so a SIGHUP reload racing
+// with an in-flight cleanup job can't observe a half-updated value.
+// Readers that can run concurrently with a reload (GetOldBuilds, the
+// cleanup cron) must go through CurrentCleanupBatchSize/
+// currentDeletePassedTestCutoff rather than reading the vars directly.
+var runtimeConfigMu sync.RWMutex
+
+// SetCleanupBatchSize updates CleanupBatchSize for subsequent cleanup
+// runs. It is the only supported way to change it after startup; callers
+// reloading config on SIGHUP should use this instead of assigning the
+// package var directly.
+func SetCleanupBatchSize(n int) {
+	runtimeConfigMu.Lock()
+	defer runtimeConfigMu.Unlock()
+	CleanupBatchSize = n
+}
+
+// CurrentCleanupBatchSize returns CleanupBatchSize under runtimeConfigMu,
+// safe to call concurrently with SetCleanupBatchSize.
+func CurrentCleanupBatchSize() int {
+	runtimeConfigMu.RLock()
+	defer runtimeConfigMu.RUnlock()
+	return CleanupBatchSize
+}
+
+// SetDeletePassedTestCutoff updates deletePassedTestCutoff for subsequent
+// cleanup runs.
+func SetDeletePassedTestCutoff(d time.Duration) {
+	runtimeConfigMu.Lock()
+	defer runtimeConfigMu.Unlock()
+	deletePassedTestCutoff = d
+}
+
+// currentDeletePassedTestCutoff returns deletePassedTestCutoff under
+// runtimeConfigMu, safe to call concurrently with
+// SetDeletePassedTestCutoff.
+func currentDeletePassedTestCutoff() time.Duration {
+	runtimeConfigMu.RLock()
+	defer runtimeConfigMu.RUnlock()
+	return deletePassedTestCutoff
+}
@@ -2,6 +2,7 @@ package logkeeper
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -184,6 +185,47 @@ func TestNoErrorWithNoLogsOrTests(t *testing.T) {
 	assert.Equal(1, deletedStats.NumLogs)
 }
 
+// TestConcurrentCleanupClaimsBuildOnce races two goroutines calling
+// CleanupOldLogsAndTestsByBuild for the same build, as two replicas
+// sharing a --queueType=remote cleanup queue would. ClaimBuildForCleanup's
+// FindOneAndDelete guard should let exactly one of them see the build and
+// do the deleting; the loser should come back with a zero DeletedStats
+// and no error, never a double-delete or a race error.
+func TestConcurrentCleanupClaimsBuildOnce(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	initTestDB(ctx, t)
+	clearCollections(t, buildsCollection, testsCollection, logsCollection)
+
+	assert := assert.New(t)
+
+	ids := insertBuilds(t)
+	insertTests(t, ids)
+	insertLogs(t, ids)
+
+	var wg sync.WaitGroup
+	results := make([]DeletedStats, 2)
+	errs := make([]error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = CleanupOldLogsAndTestsByBuild(ids[0])
+		}(i)
+	}
+	wg.Wait()
+
+	assert.NoError(errs[0])
+	assert.NoError(errs[1])
+
+	claimed := results[0].NumBuilds + results[1].NumBuilds
+	assert.Equal(1, claimed, "exactly one caller should have claimed the build")
+
+	count, _ := db.C(buildsCollection).CountDocuments(env.Context(), bson.M{"_id": ids[0]})
+	assert.EqualValues(0, count)
+}
+
 func TestUpdateFailedTest(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -0,0 +1,274 @@
+package logkeeper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/evergreen-ci/logkeeper/db"
+	"github.com/evergreen-ci/logkeeper/env"
+	"github.com/evergreen-ci/logkeeper/livelog"
+	"github.com/evergreen-ci/logkeeper/logstore"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// backfillLines caps how many historical lines a new tail subscriber is
+// sent from Mongo before switching over to the live broker.
+const backfillLines = 100
+
+var tailUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// GetURL returns the URL a caller (e.g. an Evergreen task) should use to
+// tail a build's combined log stream while it is in progress.
+func GetURL(baseURL, buildID string) string {
+	return fmt.Sprintf("%s/build/%s/tail", baseURL, buildID)
+}
+
+// GetTestURL returns the URL to tail a single test's log stream.
+func GetTestURL(baseURL, buildID, testID string) string {
+	return fmt.Sprintf("%s/build/%s/test/%s/tail", baseURL, buildID, testID)
+}
+
+func buildStreamID(buildID string) string {
+	return buildID
+}
+
+func testStreamID(buildID, testID string) string {
+	return buildID + "/" + testID
+}
+
+// publishLine fans a freshly-ingested line out to any live tail
+// subscribers for the given build and, if set, test. Ingestion handlers
+// call this after they persist a line so that concurrent tail subscribers
+// see it immediately.
+func publishLine(broker *livelog.Broker, buildID, testID string, seq int, text string, ts int64) {
+	line := livelog.Line{Seq: seq, Ts: time.Unix(0, ts), Text: text}
+	broker.Publish(buildStreamID(buildID), line)
+	if testID != "" {
+		broker.Publish(testStreamID(buildID, testID), line)
+	}
+}
+
+// backfill loads the last backfillLines lines for a build (optionally
+// scoped to a single test) from the logsCollection, ordered oldest to
+// newest, so a new subscriber can catch up before switching to the live
+// broker.
+func backfill(buildID, testID string) ([]livelog.Line, error) {
+	filter := bson.M{"build_id": buildID}
+	if testID != "" {
+		filter["test_id"] = testID
+	}
+
+	cursor, err := db.C(logsCollection).Find(env.Context(), filter,
+		options.Find().SetSort(bson.M{"seq": -1}).SetLimit(backfillLines))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding backfill logs")
+	}
+	defer cursor.Close(env.Context())
+
+	var docs []Log
+	if err := cursor.All(env.Context(), &docs); err != nil {
+		return nil, errors.Wrap(err, "decoding backfill logs")
+	}
+
+	lines := make([]livelog.Line, 0, len(docs))
+	for i := len(docs) - 1; i >= 0; i-- {
+		text, err := logLines(docs[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading log chunk for build '%s'", buildID)
+		}
+		for _, line := range text {
+			lines = append(lines, livelog.Line{Seq: docs[i].Seq, Text: line})
+		}
+	}
+	if len(lines) > backfillLines {
+		lines = lines[len(lines)-backfillLines:]
+	}
+	return lines, nil
+}
+
+// logLines returns doc's lines, reading them from CurrentLogStore if doc
+// was written through one (BlobRef set) rather than inline.
+func logLines(doc Log) ([]string, error) {
+	if doc.BlobRef == "" {
+		return doc.Lines, nil
+	}
+
+	store := CurrentLogStore()
+	if store == nil {
+		return nil, errors.New("log chunk references a blob but no log store is configured")
+	}
+
+	reader, err := store.Get(env.Context(), logstore.BlobRef(doc.BlobRef))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading log blob")
+	}
+	defer reader.Close()
+
+	return logstore.DecodeLines(reader)
+}
+
+// lastSeq returns the highest sequence number in lines, or -1 if empty.
+func lastSeq(lines []livelog.Line) int {
+	if len(lines) == 0 {
+		return -1
+	}
+	return lines[len(lines)-1].Seq
+}
+
+func tailStream(w http.ResponseWriter, r *http.Request, streamID string, broker *livelog.Broker, backfillLines []livelog.Line) {
+	upgrade := websocket.IsWebSocketUpgrade(r)
+
+	sub := broker.Subscribe(streamID)
+	defer broker.Unsubscribe(streamID, sub)
+
+	after := lastSeq(backfillLines)
+
+	if upgrade {
+		streamOverWebsocket(w, r, backfillLines, sub, after)
+		return
+	}
+	streamOverSSE(w, backfillLines, sub, after)
+}
+
+func streamOverWebsocket(w http.ResponseWriter, r *http.Request, backfill []livelog.Line, sub *livelog.Subscriber, after int) {
+	conn, err := tailUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		grip.Warning(errors.Wrap(err, "upgrading tail connection to websocket"))
+		return
+	}
+	defer conn.Close()
+
+	for _, line := range backfill {
+		if err := conn.WriteJSON(line); err != nil {
+			return
+		}
+	}
+
+	for line := range sub.C() {
+		if line.Seq <= after {
+			continue
+		}
+		if err := conn.WriteJSON(line); err != nil {
+			return
+		}
+	}
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+func streamOverSSE(w http.ResponseWriter, backfill []livelog.Line, sub *livelog.Subscriber, after int) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	writeSSELine := func(line livelog.Line) bool {
+		data, err := json.Marshal(line)
+		if err != nil {
+			return true
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	for _, line := range backfill {
+		if !writeSSELine(line) {
+			return
+		}
+	}
+
+	for line := range sub.C() {
+		if line.Seq <= after {
+			continue
+		}
+		if !writeSSELine(line) {
+			return
+		}
+	}
+}
+
+// tailAuthorized loads buildID's metadata and, if the build is private,
+// checks the request's principal against it the same way getBuildHandler
+// does. Live-tail is the only way this service exposes log content for
+// reading, so it needs the same gate as the metadata endpoint.
+func tailAuthorized(r *http.Request, opts Options, buildID string) bool {
+	build, err := loadBuild(buildID)
+	if err != nil {
+		return false
+	}
+	if !buildIsPrivate(build.Info) {
+		return true
+	}
+	project, builder := buildProjectBuilder(build.Info)
+	return authorized(r, opts, project, builder)
+}
+
+// tailBuildHandler handles GET /build/{id}/tail.
+func tailBuildHandler(opts Options, broker *livelog.Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		buildID := mux.Vars(r)["id"]
+
+		if !tailAuthorized(r, opts, buildID) {
+			http.Error(w, "not authorized for this build", http.StatusForbidden)
+			return
+		}
+
+		lines, err := backfill(buildID, "")
+		if err != nil {
+			grip.Error(errors.Wrap(err, "loading tail backfill"))
+			http.Error(w, "failed to load backfill", http.StatusInternalServerError)
+			return
+		}
+
+		tailStream(w, r, buildStreamID(buildID), broker, lines)
+	}
+}
+
+// tailTestHandler handles GET /build/{id}/test/{tid}/tail.
+func tailTestHandler(opts Options, broker *livelog.Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		buildID := vars["id"]
+		testID := vars["tid"]
+
+		if !tailAuthorized(r, opts, buildID) {
+			http.Error(w, "not authorized for this build", http.StatusForbidden)
+			return
+		}
+
+		lines, err := backfill(buildID, testID)
+		if err != nil {
+			grip.Error(errors.Wrap(err, "loading tail backfill"))
+			http.Error(w, "failed to load backfill", http.StatusInternalServerError)
+			return
+		}
+
+		tailStream(w, r, testStreamID(buildID, testID), broker, lines)
+	}
+}
+
+// AttachTailRoutes mounts the live-tail endpoints onto router, fanning out
+// lines published to broker by the ingestion handlers.
+func AttachTailRoutes(router *mux.Router, opts Options, broker *livelog.Broker) {
+	router.HandleFunc("/build/{id}/tail", tailBuildHandler(opts, broker)).Methods("GET")
+	router.HandleFunc("/build/{id}/test/{tid}/tail", tailTestHandler(opts, broker)).Methods("GET")
+}
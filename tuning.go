@@ -0,0 +1,29 @@
+package logkeeper
+
+import "time"
+
+// CleanupBatchSize and deletePassedTestCutoff bound each cleanup sweep:
+// GetOldBuilds returns at most CleanupBatchSize builds started more than
+// deletePassedTestCutoff ago. Both have config/flag-driven defaults and
+// are otherwise only changed via SetCleanupBatchSize/
+// SetDeletePassedTestCutoff (see runtime_config.go). Code that can run
+// concurrently with a SIGHUP reload must read them back through
+// CurrentCleanupBatchSize/currentDeletePassedTestCutoff rather than the
+// vars directly.
+var (
+	CleanupBatchSize       = 1000
+	deletePassedTestCutoff = 14 * 24 * time.Hour
+)
+
+// Amboy tuning for the cleanup queue's worker pool. QueueSizeCap bounds
+// how much pending work queue.NewLocalLimitedSize keeps buffered before it
+// starts rejecting new jobs.
+const (
+	AmboyWorkers       = 4
+	AmboyTargetNumJobs = 1
+	QueueSizeCap       = 10000
+)
+
+// AmboyInterval is the moving-average window the cleanup queue's rate
+// limiter uses.
+var AmboyInterval = time.Minute
@@ -0,0 +1,77 @@
+package units
+
+import (
+	"context"
+	"time"
+
+	"github.com/evergreen-ci/logkeeper"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const cleanupOldBuildsJobName = "cleanup-old-builds"
+
+func init() {
+	registry.AddJobType(cleanupOldBuildsJobName, func() amboy.Job { return makeCleanupOldBuildsJob() })
+}
+
+// cleanupOldBuildsJob sweeps logkeeper.GetOldBuilds and deletes each one
+// via logkeeper.CleanupOldLogsAndTestsByBuild. That function claims the
+// build with logkeeper.ClaimBuildForCleanup before deleting anything, so
+// running this job from multiple replicas draining the same
+// --queueType=remote queue is safe: only the replica that wins the claim
+// for a given build deletes its tests and logs.
+type cleanupOldBuildsJob struct {
+	job.Base `bson:"job_base" json:"job_base" yaml:"job_base"`
+}
+
+func makeCleanupOldBuildsJob() *cleanupOldBuildsJob {
+	j := &cleanupOldBuildsJob{
+		Base: job.Base{JobType: amboy.JobType{Name: cleanupOldBuildsJobName, Version: 0}},
+	}
+	j.SetID(cleanupOldBuildsJobName)
+	return j
+}
+
+// NewCleanupOldBuildsJob returns a job that sweeps and deletes old builds.
+func NewCleanupOldBuildsJob(ts time.Time) amboy.Job {
+	j := makeCleanupOldBuildsJob()
+	j.SetID(cleanupOldBuildsJobName + "." + ts.Format(time.RFC3339))
+	return j
+}
+
+func (j *cleanupOldBuildsJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+
+	builds, err := logkeeper.GetOldBuilds(logkeeper.CurrentCleanupBatchSize())
+	if err != nil {
+		j.AddError(errors.Wrap(err, "finding old builds"))
+		return
+	}
+
+	var claimed, lost int
+	for _, build := range builds {
+		stats, err := logkeeper.CleanupOldLogsAndTestsByBuild(build.Id)
+		if err != nil {
+			j.AddError(errors.Wrapf(err, "cleaning up build '%s'", build.Id))
+			continue
+		}
+		if stats.NumBuilds == 0 {
+			// Another replica's worker won the claim for this build first.
+			lost++
+			continue
+		}
+		claimed++
+	}
+
+	grip.Info(message.Fields{
+		"message": "completed old build cleanup sweep",
+		"found":   len(builds),
+		"claimed": claimed,
+		"lost":    lost,
+	})
+}
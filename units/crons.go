@@ -0,0 +1,62 @@
+package units
+
+import (
+	"context"
+	"time"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/recovery"
+	"github.com/pkg/errors"
+)
+
+// cleanupInterval and reconcileInterval space out how often StartCrons
+// enqueues its recurring jobs onto the cleanup queue.
+const (
+	cleanupInterval   = time.Minute
+	reconcileInterval = time.Hour
+)
+
+// StartCrons launches the background goroutines that periodically enqueue
+// logkeeper's recurring maintenance jobs onto q: a sweep of old builds,
+// and, once SetReconcileStore has configured a log store, an audit of it
+// against the logs metadata collection for orphans in either direction.
+func StartCrons(ctx context.Context, q amboy.Queue) error {
+	go runCron(ctx, q, cleanupInterval, func(ts time.Time) amboy.Job {
+		return NewCleanupOldBuildsJob(ts)
+	})
+
+	go runCron(ctx, q, reconcileInterval, func(ts time.Time) amboy.Job {
+		store := CurrentReconcileStore()
+		if store == nil {
+			return nil
+		}
+		return NewReconcileBlobsJob(store, ts)
+	})
+
+	return nil
+}
+
+// runCron enqueues the job makeJob returns onto q every interval, until ctx
+// is canceled. makeJob returning nil skips that tick.
+func runCron(ctx context.Context, q amboy.Queue, interval time.Duration, makeJob func(time.Time) amboy.Job) {
+	defer recovery.LogStackTraceAndContinue("cron scheduler")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ts := <-ticker.C:
+			j := makeJob(ts)
+			if j == nil {
+				continue
+			}
+			if err := q.Put(ctx, j); err != nil {
+				grip.Warning(errors.Wrap(err, "enqueueing cron job"))
+			}
+		}
+	}
+}
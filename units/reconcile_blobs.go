@@ -0,0 +1,146 @@
+package units
+
+import (
+	"context"
+	"time"
+
+	"github.com/evergreen-ci/logkeeper/db"
+	"github.com/evergreen-ci/logkeeper/logstore"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const reconcileBlobsJobName = "reconcile-log-blobs"
+
+// logsCollection mirrors the collection name the logkeeper package stores
+// log metadata in.
+const logsCollection = "logs"
+
+func init() {
+	registry.AddJobType(reconcileBlobsJobName, func() amboy.Job { return makeReconcileBlobsJob() })
+}
+
+// logBlobMeta mirrors the subset of the logs metadata document that
+// reconciliation needs: the blob it points at.
+type logBlobMeta struct {
+	BlobRef string `bson:"blob_ref"`
+}
+
+// reconcileBlobsJob finds logsCollection metadata documents whose blob no
+// longer exists in the configured LogStore, and blobs that have no
+// corresponding metadata document, and reports both so an operator can
+// decide whether to re-run cleanup or restore from backup. It does not
+// delete anything itself: orphaned metadata and orphaned blobs are both
+// symptoms of a bug elsewhere, and silently deleting either could destroy
+// evidence needed to find it.
+type reconcileBlobsJob struct {
+	job.Base `bson:"job_base" json:"job_base" yaml:"job_base"`
+
+	Store logstore.LogStore `bson:"-" json:"-" yaml:"-"`
+}
+
+func makeReconcileBlobsJob() *reconcileBlobsJob {
+	j := &reconcileBlobsJob{
+		Base: job.Base{
+			JobType: amboy.JobType{Name: reconcileBlobsJobName, Version: 0},
+		},
+	}
+	j.SetID(reconcileBlobsJobName)
+	return j
+}
+
+// NewReconcileBlobsJob returns a job that audits store against the logs
+// metadata collection for orphans in either direction.
+func NewReconcileBlobsJob(store logstore.LogStore, ts time.Time) amboy.Job {
+	j := makeReconcileBlobsJob()
+	j.Store = store
+	j.SetID(reconcileBlobsJobName + "." + ts.Format(time.RFC3339))
+	return j
+}
+
+func (j *reconcileBlobsJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+
+	if j.Store == nil {
+		j.AddError(errors.New("no log store configured for blob reconciliation"))
+		return
+	}
+
+	metaRefs, missingBlobs := j.findMissingBlobs(ctx)
+
+	var orphanedBlobs int
+	if lister, ok := j.Store.(logstore.ListableStore); ok {
+		orphanedBlobs = j.findOrphanedBlobs(ctx, lister, metaRefs)
+	} else {
+		grip.Info("log store does not support listing; skipping orphaned-blob detection")
+	}
+
+	grip.Info(message.Fields{
+		"message":        "completed log blob reconciliation",
+		"missing_blobs":  missingBlobs,
+		"orphaned_blobs": orphanedBlobs,
+	})
+}
+
+// findMissingBlobs reports metadata documents whose blob no longer exists
+// in j.Store, and returns every blob_ref it saw along the way so
+// findOrphanedBlobs can check the other direction without a second pass
+// over logsCollection.
+func (j *reconcileBlobsJob) findMissingBlobs(ctx context.Context) (map[string]bool, int) {
+	refs := make(map[string]bool)
+
+	cursor, err := db.C(logsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		j.AddError(errors.Wrap(err, "finding log metadata documents"))
+		return refs, 0
+	}
+	defer cursor.Close(ctx)
+
+	var missingBlobs int
+	for cursor.Next(ctx) {
+		var meta logBlobMeta
+		if err := cursor.Decode(&meta); err != nil {
+			j.AddError(errors.Wrap(err, "decoding log metadata document"))
+			continue
+		}
+		if meta.BlobRef == "" {
+			continue
+		}
+		refs[meta.BlobRef] = true
+
+		reader, err := j.Store.Get(ctx, logstore.BlobRef(meta.BlobRef))
+		if err != nil {
+			missingBlobs++
+			continue
+		}
+		_ = reader.Close()
+	}
+	if err := cursor.Err(); err != nil {
+		j.AddError(errors.Wrap(err, "iterating log metadata documents"))
+	}
+
+	return refs, missingBlobs
+}
+
+// findOrphanedBlobs reports blobs in lister that no metadata document
+// references, the "vice versa" case findMissingBlobs can't see on its own.
+func (j *reconcileBlobsJob) findOrphanedBlobs(ctx context.Context, lister logstore.ListableStore, metaRefs map[string]bool) int {
+	blobs, err := lister.List(ctx)
+	if err != nil {
+		j.AddError(errors.Wrap(err, "listing log store blobs"))
+		return 0
+	}
+
+	var orphaned int
+	for _, ref := range blobs {
+		if !metaRefs[string(ref)] {
+			orphaned++
+		}
+	}
+	return orphaned
+}
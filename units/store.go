@@ -0,0 +1,30 @@
+package units
+
+import (
+	"sync"
+
+	"github.com/evergreen-ci/logkeeper/logstore"
+)
+
+var (
+	reconcileStoreMu sync.RWMutex
+	reconcileStore   logstore.LogStore
+)
+
+// SetReconcileStore installs store as the LogStore StartCrons audits with
+// a recurring reconcileBlobsJob. Leaving it unset (the default) disables
+// blob reconciliation, matching logkeeper's historical inline-in-Mongo
+// behavior where there is no separate blob store to audit.
+func SetReconcileStore(store logstore.LogStore) {
+	reconcileStoreMu.Lock()
+	defer reconcileStoreMu.Unlock()
+	reconcileStore = store
+}
+
+// CurrentReconcileStore returns the store set by SetReconcileStore, or nil
+// if none has been configured.
+func CurrentReconcileStore() logstore.LogStore {
+	reconcileStoreMu.RLock()
+	defer reconcileStoreMu.RUnlock()
+	return reconcileStore
+}